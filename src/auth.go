@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Credentials identify a client to an AuthService.
+type Credentials struct {
+	ClientID string
+	Secret   string
+}
+
+// Token is an access token issued by AuthService.Authenticate.
+type Token struct {
+	Value     string
+	ExpiresAt time.Time
+}
+
+// AuthService wraps an ExternalService and rejects calls that carry no
+// valid token with ErrUnauthorized. A call is authorized by the token in
+// its context (see ContextWithToken) or, failing that, by the token most
+// recently issued to the service's own client by Connect or Authenticate.
+// Tokens expire TTL after they are issued, after which calls fail with
+// ErrTokenExpired until the client re-authenticates.
+type AuthService struct {
+	// TTL is how long issued tokens stay valid.
+	TTL time.Duration
+
+	// Clock, when set, replaces the system clock for token expiry.
+	Clock Clock
+
+	next  ExternalService
+	creds Credentials
+
+	mu     sync.Mutex
+	issued map[string]time.Time // token value to expiry
+	count  int                  // tokens issued so far, for naming
+	token  Token
+}
+
+// NewAuthService wraps next so that calls need a token obtained from
+// Authenticate with creds, or from Connect, which authenticates with
+// creds itself. Tokens last ttl.
+func NewAuthService(next ExternalService, creds Credentials, ttl time.Duration) *AuthService {
+	return &AuthService{TTL: ttl, next: next, creds: creds}
+}
+
+// Authenticate exchanges creds for a new token, which also becomes the
+// token used for calls whose context carries none. Earlier tokens stay
+// valid until they expire. Expired tokens keep failing with
+// ErrTokenExpired for a further TTL, after which the next call to
+// Authenticate forgets them. Wrong credentials fail with ErrUnauthorized.
+func (s *AuthService) Authenticate(ctx context.Context, creds Credentials) (Token, error) {
+	if err := ctx.Err(); err != nil {
+		return Token{}, err
+	}
+	if creds != s.creds {
+		return Token{}, fmt.Errorf("invalid credentials for client %q: %w", creds.ClientID, ErrUnauthorized)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.issued == nil {
+		s.issued = make(map[string]time.Time)
+	}
+	now := s.now()
+	for value, expires := range s.issued {
+		if !now.Before(expires.Add(s.TTL)) {
+			delete(s.issued, value)
+		}
+	}
+	s.count++
+	s.token = Token{
+		Value:     fmt.Sprintf("%s-token-%d", creds.ClientID, s.count),
+		ExpiresAt: now.Add(s.TTL),
+	}
+	s.issued[s.token.Value] = s.token.ExpiresAt
+	return s.token, nil
+}
+
+// Connect exchanges the service's credentials for a token, then connects
+// the wrapped service.
+func (s *AuthService) Connect(ctx context.Context) error {
+	if _, err := s.Authenticate(ctx, s.creds); err != nil {
+		return err
+	}
+	return s.next.Connect(ctx)
+}
+
+func (s *AuthService) Ping(ctx context.Context) error {
+	if err := s.authorize(ctx); err != nil {
+		return err
+	}
+	return s.next.Ping(ctx)
+}
+
+func (s *AuthService) GetData(ctx context.Context, key string) (string, error) {
+	if err := s.authorize(ctx); err != nil {
+		return "", err
+	}
+	return s.next.GetData(ctx, key)
+}
+
+func (s *AuthService) PutData(ctx context.Context, key string, value string) error {
+	if err := s.authorize(ctx); err != nil {
+		return err
+	}
+	return s.next.PutData(ctx, key, value)
+}
+
+func (s *AuthService) DeleteData(ctx context.Context, key string) error {
+	if err := s.authorize(ctx); err != nil {
+		return err
+	}
+	return s.next.DeleteData(ctx, key)
+}
+
+func (s *AuthService) ListKeys(ctx context.Context) ([]string, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return s.next.ListKeys(ctx)
+}
+
+// authorize fails unless ctx's token, or else the service's own, was
+// issued by s and has not expired.
+func (s *AuthService) authorize(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := TokenFromContext(ctx)
+	if !ok {
+		token = s.token.Value
+	}
+	if token == "" {
+		return fmt.Errorf("no token: %w", ErrUnauthorized)
+	}
+	expires, ok := s.issued[token]
+	if !ok {
+		return fmt.Errorf("unknown token %q: %w", token, ErrUnauthorized)
+	}
+	if !s.now().Before(expires) {
+		return fmt.Errorf("token %q expired at %s: %w", token, expires.Format(time.RFC3339), ErrTokenExpired)
+	}
+	return nil
+}
+
+func (s *AuthService) now() time.Time {
+	if s.Clock == nil {
+		return time.Now()
+	}
+	return s.Clock.Now()
+}
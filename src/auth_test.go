@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAuthService(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(clockEpoch)
+	creds := Credentials{ClientID: "demo", Secret: "s3cret"}
+	svc := NewAuthService(NewMockService("API", WithDryRun(true)), creds, time.Minute)
+	svc.Clock = clock
+
+	if err := svc.PutData(ctx, "key", "value"); !errors.Is(err, ErrUnauthorized) || IsRetryable(err) {
+		t.Fatalf("Expected a permanent ErrUnauthorized before authenticating, got %v", err)
+	}
+	if _, err := svc.Authenticate(ctx, Credentials{ClientID: "demo", Secret: "wrong"}); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("Expected wrong credentials to be rejected, got %v", err)
+	}
+
+	token, err := svc.Authenticate(ctx, creds)
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if want := clockEpoch.Add(time.Minute); !token.ExpiresAt.Equal(want) {
+		t.Errorf("Expected the token to expire at %v, got %v", want, token.ExpiresAt)
+	}
+	if err := svc.PutData(ctx, "key", "value"); err != nil {
+		t.Fatalf("Expected an authenticated write to succeed, got %v", err)
+	}
+
+	clock.Advance(time.Minute)
+	if _, err := svc.GetData(ctx, "key"); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("Expected an expired token to block reads, got %v", err)
+	}
+
+	refreshed, err := svc.Authenticate(ctx, creds)
+	if err != nil {
+		t.Fatalf("Re-authenticating failed: %v", err)
+	}
+	if refreshed.Value == token.Value {
+		t.Errorf("Expected a fresh token, got %q again", refreshed.Value)
+	}
+	if got, err := svc.GetData(ctx, "key"); err != nil || got != "value" {
+		t.Errorf("Expected re-authentication to restore access, got %q, %v", got, err)
+	}
+}
+
+func TestAuthServiceContextToken(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(clockEpoch)
+	creds := Credentials{ClientID: "demo", Secret: "s3cret"}
+	svc := NewAuthService(NewMockService("API", WithDryRun(true)), creds, time.Minute)
+	svc.Clock = clock
+
+	if err := svc.Ping(ctx); !errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("Expected ErrUnauthorized without a token, got %v", err)
+	}
+	if err := svc.Ping(ContextWithToken(ctx, "forged")); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("Expected an unknown token to be rejected, got %v", err)
+	}
+
+	// Connect exchanges the service's credentials for a token.
+	if err := svc.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := svc.PutData(ctx, "key", "value"); err != nil {
+		t.Fatalf("Expected the connected client to be authorized, got %v", err)
+	}
+
+	clock.Advance(30 * time.Second)
+	token, err := svc.Authenticate(ctx, creds)
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	tokenCtx := ContextWithToken(ctx, token.Value)
+	if got, err := svc.GetData(tokenCtx, "key"); err != nil || got != "value" {
+		t.Fatalf("Expected the context token to authorize the read, got %q, %v", got, err)
+	}
+
+	// The first token expires while the second is still valid.
+	clock.Advance(30 * time.Second)
+	if err := svc.Ping(tokenCtx); err != nil {
+		t.Errorf("Expected the unexpired context token to work, got %v", err)
+	}
+	clock.Advance(30 * time.Second)
+	err = svc.Ping(tokenCtx)
+	if !errors.Is(err, ErrTokenExpired) || !errors.Is(err, ErrUnauthorized) || IsRetryable(err) {
+		t.Fatalf("Expected a permanent ErrTokenExpired, got %v", err)
+	}
+
+	// Refreshing issues a new token that works again.
+	if err := svc.Connect(ctx); err != nil {
+		t.Fatalf("Reconnecting failed: %v", err)
+	}
+	if err := svc.Ping(ctx); err != nil {
+		t.Errorf("Expected the refreshed token to work, got %v", err)
+	}
+	if err := svc.Ping(tokenCtx); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("Expected the old token to stay expired, got %v", err)
+	}
+}
+
+func TestAuthServicePrunesExpiredTokens(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(clockEpoch)
+	creds := Credentials{ClientID: "demo", Secret: "s3cret"}
+	svc := NewAuthService(NewMockService("API", WithDryRun(true)), creds, time.Minute)
+	svc.Clock = clock
+
+	seen := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		token, err := svc.Authenticate(ctx, creds)
+		if err != nil {
+			t.Fatalf("Authenticate failed: %v", err)
+		}
+		if seen[token.Value] {
+			t.Fatalf("Expected unique token names, got %q twice", token.Value)
+		}
+		seen[token.Value] = true
+		clock.Advance(2 * time.Minute)
+	}
+	svc.mu.Lock()
+	issued := len(svc.issued)
+	svc.mu.Unlock()
+	if issued != 1 {
+		t.Errorf("Expected tokens expired for over a TTL to be removed, got %d issued", issued)
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Backend is a service behind a LoadBalancer and its share of the calls.
+type Backend struct {
+	Service ExternalService
+	Weight  int
+}
+
+// Defaults for LoadBalancer health tracking.
+const (
+	defaultFailureThreshold = 3
+	defaultCooldown         = 30 * time.Second
+)
+
+// LoadBalancer is an ExternalService that spreads reads across backends by
+// weight using smooth weighted round-robin. Writes and deletes go to every
+// healthy backend, so a read after a write sees it whichever backend
+// serves it; a backend out of rotation misses the writes made meanwhile.
+// A backend that fails FailureThreshold calls in a row with retryable
+// errors is considered unhealthy and skipped for Cooldown; this health
+// tracking acts as a circuit breaker per backend. A read that fails with a
+// retryable error falls back to the next healthy backend. Connect and Ping
+// reach every backend, and a backend that answers them is put back in
+// rotation.
+type LoadBalancer struct {
+	// FailureThreshold is the number of consecutive retryable failures
+	// after which a backend is taken out of rotation.
+	FailureThreshold int
+
+	// Cooldown is how long an unhealthy backend stays out of rotation.
+	Cooldown time.Duration
+
+	// Clock, when set, replaces the system clock for health tracking.
+	Clock Clock
+
+	mu       sync.Mutex
+	backends []*lbBackend
+}
+
+type lbBackend struct {
+	svc     ExternalService
+	weight  int
+	current int
+	// failures counts consecutive retryable failures.
+	failures  int
+	downUntil time.Time
+}
+
+// NewLoadBalancer creates a LoadBalancer over backends. Backends with a
+// weight below one get a weight of one.
+func NewLoadBalancer(backends ...Backend) *LoadBalancer {
+	lb := &LoadBalancer{
+		FailureThreshold: defaultFailureThreshold,
+		Cooldown:         defaultCooldown,
+	}
+	for _, b := range backends {
+		lb.backends = append(lb.backends, &lbBackend{svc: b.Service, weight: max(b.Weight, 1)})
+	}
+	return lb
+}
+
+// Connect connects every backend, including those out of rotation, and
+// fails if any of them does.
+func (lb *LoadBalancer) Connect(ctx context.Context) error {
+	return lb.broadcast(lb.backends, func(svc ExternalService) error { return svc.Connect(ctx) })
+}
+
+// Ping checks every backend, including those out of rotation, and fails if
+// any of them does.
+func (lb *LoadBalancer) Ping(ctx context.Context) error {
+	return lb.broadcast(lb.backends, func(svc ExternalService) error { return svc.Ping(ctx) })
+}
+
+func (lb *LoadBalancer) GetData(ctx context.Context, key string) (value string, err error) {
+	err = lb.do(ctx, func(svc ExternalService) error {
+		value, err = svc.GetData(ctx, key)
+		return err
+	})
+	return value, err
+}
+
+// PutData writes to every healthy backend and fails if any of them does.
+func (lb *LoadBalancer) PutData(ctx context.Context, key string, value string) error {
+	return lb.broadcast(lb.healthy(), func(svc ExternalService) error { return svc.PutData(ctx, key, value) })
+}
+
+// DeleteData deletes from every healthy backend. It returns ErrNotFound
+// only if no backend held key.
+func (lb *LoadBalancer) DeleteData(ctx context.Context, key string) error {
+	return lb.broadcast(lb.healthy(), func(svc ExternalService) error { return svc.DeleteData(ctx, key) })
+}
+
+func (lb *LoadBalancer) ListKeys(ctx context.Context) (keys []string, err error) {
+	err = lb.do(ctx, func(svc ExternalService) error {
+		keys, err = svc.ListKeys(ctx)
+		return err
+	})
+	return keys, err
+}
+
+// Healthy reports how many backends are currently in rotation.
+func (lb *LoadBalancer) Healthy() int {
+	return len(lb.healthy())
+}
+
+// healthy returns the backends currently in rotation.
+func (lb *LoadBalancer) healthy() []*lbBackend {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	now := lb.now()
+	var backends []*lbBackend
+	for _, b := range lb.backends {
+		if !now.Before(b.downUntil) {
+			backends = append(backends, b)
+		}
+	}
+	return backends
+}
+
+// do calls fn on backends in weighted order until one succeeds or fails
+// with an error that is not worth retrying elsewhere.
+func (lb *LoadBalancer) do(ctx context.Context, fn func(ExternalService) error) error {
+	tried := make(map[*lbBackend]bool, len(lb.backends))
+	var errs []error
+	for {
+		b := lb.pick(tried)
+		if b == nil {
+			if len(errs) == 0 {
+				return fmt.Errorf("no healthy backends: %w", ErrUnavailable)
+			}
+			return errors.Join(errs...)
+		}
+		tried[b] = true
+
+		err := fn(b.svc)
+		lb.report(b, err)
+		if err == nil || !IsRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+		errs = append(errs, err)
+	}
+}
+
+// broadcast calls fn on each of backends, returning the failures joined
+// with errors.Join. ErrNotFound counts as a failure only if every backend
+// returned it.
+func (lb *LoadBalancer) broadcast(backends []*lbBackend, fn func(ExternalService) error) error {
+	if len(backends) == 0 {
+		return fmt.Errorf("no healthy backends: %w", ErrUnavailable)
+	}
+	var errs []error
+	var notFound error
+	missing := 0
+	for _, b := range backends {
+		err := fn(b.svc)
+		lb.report(b, err)
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrNotFound):
+			notFound = err
+			missing++
+		default:
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if missing == len(backends) {
+		return notFound
+	}
+	return nil
+}
+
+// pick chooses the next healthy backend not in tried, or nil if none is
+// left.
+func (lb *LoadBalancer) pick(tried map[*lbBackend]bool) *lbBackend {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	now := lb.now()
+	var best *lbBackend
+	total := 0
+	for _, b := range lb.backends {
+		if tried[b] || now.Before(b.downUntil) {
+			continue
+		}
+		b.current += b.weight
+		total += b.weight
+		if best == nil || b.current > best.current {
+			best = b
+		}
+	}
+	if best != nil {
+		best.current -= total
+	}
+	return best
+}
+
+// report updates b's health after a call that returned err. A success
+// puts b back in rotation.
+func (lb *LoadBalancer) report(b *lbBackend, err error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	if err == nil {
+		b.downUntil = time.Time{}
+	}
+	if !IsRetryable(err) {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= lb.FailureThreshold {
+		b.downUntil = lb.now().Add(lb.Cooldown)
+		b.failures = 0
+	}
+}
+
+func (lb *LoadBalancer) now() time.Time {
+	if lb.Clock == nil {
+		return time.Now()
+	}
+	return lb.Clock.Now()
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoadBalancerWeights(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	weights := []int{5, 3, 2}
+	services := make([]*MockService, len(weights))
+	backends := make([]Backend, len(weights))
+	for i, w := range weights {
+		services[i] = NewMockService("Backend", WithStore(store))
+		backends[i] = Backend{Service: services[i], Weight: w}
+	}
+	if err := services[0].PutData(ctx, "key", "value"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+
+	lb := NewLoadBalancer(backends...)
+	const calls = 1000
+	for i := 0; i < calls; i++ {
+		if got, err := lb.GetData(ctx, "key"); err != nil || got != "value" {
+			t.Fatalf("GetData = %q, %v", got, err)
+		}
+	}
+
+	for i, w := range weights {
+		want := calls * w / 10
+		got := services[i].Metrics()["GetData"].Count
+		if got < want-calls/50 || got > want+calls/50 {
+			t.Errorf("Backend %d (weight %d): expected about %d calls, got %d", i, w, want, got)
+		}
+	}
+}
+
+func TestLoadBalancerSkipsUnhealthyBackend(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(clockEpoch)
+	store := NewMemoryStore()
+	healthy := NewMockService("Healthy", WithStore(store))
+	broken := NewMockService("Broken", WithStore(store), WithFailureRate(1.0))
+	if err := healthy.PutData(ctx, "key", "value"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+
+	lb := NewLoadBalancer(Backend{broken, 1}, Backend{healthy, 1})
+	lb.FailureThreshold = 2
+	lb.Cooldown = time.Minute
+	lb.Clock = clock
+
+	for i := 0; i < 20; i++ {
+		if got, err := lb.GetData(ctx, "key"); err != nil || got != "value" {
+			t.Fatalf("Call %d: expected fallback to the healthy backend, got %q, %v", i, got, err)
+		}
+	}
+	if got := broken.Metrics()["GetData"].Count; got != 2 {
+		t.Errorf("Expected the broken backend to be skipped after 2 failures, got %d calls", got)
+	}
+	if got := lb.Healthy(); got != 1 {
+		t.Errorf("Expected 1 healthy backend, got %d", got)
+	}
+
+	// After the cooldown the backend is tried again.
+	clock.Advance(time.Minute)
+	if got := lb.Healthy(); got != 2 {
+		t.Errorf("Expected both backends back in rotation, got %d", got)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := lb.GetData(ctx, "key"); err != nil {
+			t.Fatalf("GetData failed: %v", err)
+		}
+	}
+	if got := broken.Metrics()["GetData"].Count; got != 4 {
+		t.Errorf("Expected the broken backend to be retried after the cooldown, got %d calls", got)
+	}
+}
+
+func TestLoadBalancerAllBackendsFailing(t *testing.T) {
+	ctx := context.Background()
+	lb := NewLoadBalancer(
+		Backend{NewMockService("A", WithFailureRate(1.0)), 1},
+		Backend{NewMockService("B", WithFailureRate(1.0)), 1},
+	)
+	lb.FailureThreshold = 1
+
+	if _, err := lb.GetData(ctx, "key"); !errors.Is(err, ErrTransient) {
+		t.Errorf("Expected the backends' transient errors, got %v", err)
+	}
+	if _, err := lb.GetData(ctx, "key"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected ErrUnavailable with every backend down, got %v", err)
+	}
+}
+
+func TestLoadBalancerDoesNotRetryPermanentErrors(t *testing.T) {
+	a := NewMockService("A")
+	b := NewMockService("B")
+	lb := NewLoadBalancer(Backend{a, 1}, Backend{b, 1})
+
+	if _, err := lb.GetData(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	if calls := a.Metrics()["GetData"].Count + b.Metrics()["GetData"].Count; calls != 1 {
+		t.Errorf("Expected a permanent error not to fall back, got %d calls", calls)
+	}
+}
+
+func TestLoadBalancerReadAfterWrite(t *testing.T) {
+	ctx := context.Background()
+	backends := make([]Backend, 3)
+	services := make([]*MockService, len(backends))
+	for i := range backends {
+		services[i] = NewMockService("Backend")
+		backends[i] = Backend{Service: services[i], Weight: 1}
+	}
+	lb := NewLoadBalancer(backends...)
+
+	for i := 0; i < 10; i++ {
+		value := string(rune('a' + i))
+		if err := lb.PutData(ctx, "key", value); err != nil {
+			t.Fatalf("PutData failed: %v", err)
+		}
+		if got, err := lb.GetData(ctx, "key"); err != nil || got != value {
+			t.Fatalf("Write %d: expected to read back %q, got %q, %v", i, value, got, err)
+		}
+	}
+	for i, svc := range services {
+		if got := svc.Metrics()["PutData"].Count; got != 10 {
+			t.Errorf("Backend %d: expected every write, got %d", i, got)
+		}
+	}
+
+	if err := lb.DeleteData(ctx, "key"); err != nil {
+		t.Fatalf("DeleteData failed: %v", err)
+	}
+	for i := 0; i < len(backends); i++ {
+		if _, err := lb.GetData(ctx, "key"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected the delete to reach every backend, got %v", err)
+		}
+	}
+	if err := lb.DeleteData(ctx, "key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting a missing key, got %v", err)
+	}
+}
+
+func TestLoadBalancerConnectsEveryBackend(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(clockEpoch)
+	services := []*MockService{
+		NewMockService("A", WithStrictConnect(true)),
+		NewMockService("B", WithStrictConnect(true)),
+		NewMockService("C", WithStrictConnect(true)),
+	}
+	lb := NewLoadBalancer(Backend{services[0], 1}, Backend{services[1], 1}, Backend{services[2], 1})
+	lb.FailureThreshold = 1
+	lb.Cooldown = time.Minute
+	lb.Clock = clock
+
+	if err := lb.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	for i, svc := range services {
+		if got := svc.Metrics()["Connect"].Count; got != 1 {
+			t.Errorf("Backend %d: expected one connect, got %d", i, got)
+		}
+	}
+	for i := 0; i < 6; i++ {
+		if _, err := lb.ListKeys(ctx); err != nil {
+			t.Fatalf("Call %d: expected every backend to be connected, got %v", i, err)
+		}
+	}
+
+	// A failing backend fails Ping and drops out of rotation until it
+	// answers a Ping again.
+	services[1].Pause()
+	if err := lb.Ping(ctx); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Expected Ping to report the paused backend, got %v", err)
+	}
+	if got := lb.Healthy(); got != 2 {
+		t.Errorf("Expected 2 healthy backends, got %d", got)
+	}
+	services[1].Resume()
+	if err := lb.Ping(ctx); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if got := lb.Healthy(); got != 3 {
+		t.Errorf("Expected the backend back in rotation after a Ping, got %d", got)
+	}
+}
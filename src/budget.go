@@ -0,0 +1,23 @@
+package main
+
+import "fmt"
+
+// CheckFailureBudget returns an error if more than budget (a fraction in
+// [0, 1]) of the calls src has recorded failed. Each attempt counts, so a
+// service whose callers only succeed thanks to heavy retries still
+// exhausts its budget. A source with no recorded calls is within budget.
+func CheckFailureBudget(src MetricsSource, budget float64) error {
+	calls, failures := 0, 0
+	for _, om := range src.Metrics() {
+		calls += om.Count
+		failures += om.Failures
+	}
+	if calls == 0 {
+		return nil
+	}
+	if ratio := float64(failures) / float64(calls); ratio > budget {
+		return fmt.Errorf("%s failed %d of %d calls (%.1f%%), exceeding its %.1f%% failure budget",
+			src.Name(), failures, calls, ratio*100, budget*100)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Binary", WithDryRun(true))
+	value := []byte{0x00, 'a', 0xff, 0xfe, 0x00, 0xc3, 0x28, 0x80, 0x00}
+	if utf8.Valid(value) {
+		t.Fatal("Test value should not be valid UTF-8")
+	}
+
+	if err := svc.PutDataBytes(ctx, "blob", value); err != nil {
+		t.Fatalf("PutDataBytes failed: %v", err)
+	}
+	value[1] = 'b' // the service must have kept its own copy
+
+	got, err := svc.GetDataBytes(ctx, "blob")
+	if err != nil {
+		t.Fatalf("GetDataBytes failed: %v", err)
+	}
+	want := []byte{0x00, 'a', 0xff, 0xfe, 0x00, 0xc3, 0x28, 0x80, 0x00}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Expected %x, got %x", want, got)
+	}
+
+	// The string methods see the same bytes.
+	if s, err := svc.GetData(ctx, "blob"); err != nil || s != string(want) {
+		t.Errorf("GetData = %q, %v; want %q", s, err, want)
+	}
+	if err := svc.PutData(ctx, "text", "\x00\xff"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	if got, err := svc.GetDataBytes(ctx, "text"); err != nil || !bytes.Equal(got, []byte{0x00, 0xff}) {
+		t.Errorf("GetDataBytes = %x, %v; want 00ff", got, err)
+	}
+
+	if _, err := svc.GetDataBytes(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if err := NewMockService("Limited", WithDryRun(true), WithMaxValueSize(4)).PutDataBytes(ctx, "blob", value); !errors.Is(err, ErrValueTooLarge) {
+		t.Errorf("Expected ErrValueTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// CannedResponse is the fixed result a CannedService returns for a key.
+type CannedResponse struct {
+	Value string
+	Err   error
+}
+
+// CannedService is an ExternalService that answers reads from a fixed set
+// of responses, making tests fully deterministic. Writes and deletes are
+// accepted but never change what is read back.
+type CannedService struct {
+	responses map[string]CannedResponse
+}
+
+// NewCannedService creates a CannedService answering GetData for each key
+// in responses with exactly the configured value and error. Keys that are
+// not configured are reported as not found.
+func NewCannedService(responses map[string]CannedResponse) *CannedService {
+	c := &CannedService{responses: make(map[string]CannedResponse, len(responses))}
+	for k, v := range responses {
+		c.responses[k] = v
+	}
+	return c
+}
+
+func (c *CannedService) Connect(ctx context.Context) error {
+	return ctx.Err()
+}
+
+func (c *CannedService) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// GetData returns the response configured for key verbatim.
+func (c *CannedService) GetData(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	resp, ok := c.responses[key]
+	if !ok {
+		return "", fmt.Errorf("key %s %w", key, ErrNotFound)
+	}
+	return resp.Value, resp.Err
+}
+
+// PutData accepts the write without changing the canned responses.
+func (c *CannedService) PutData(ctx context.Context, key string, value string) error {
+	return ctx.Err()
+}
+
+// DeleteData accepts the delete without changing the canned responses.
+func (c *CannedService) DeleteData(ctx context.Context, key string) error {
+	return ctx.Err()
+}
+
+// ListKeys returns the configured keys in sorted order.
+func (c *CannedService) ListKeys(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(c.responses))
+	for k := range c.responses {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCannedService(t *testing.T) {
+	ctx := context.Background()
+	errBackend := errors.New("backend exploded")
+	var svc ExternalService = NewCannedService(map[string]CannedResponse{
+		"user:1":  {Value: "alice"},
+		"user:2":  {Value: "bob"},
+		"broken":  {Err: errBackend},
+		"partial": {Value: "stale", Err: ErrTransient},
+	})
+
+	tests := []struct {
+		key     string
+		value   string
+		wantErr error
+	}{
+		{"user:1", "alice", nil},
+		{"user:2", "bob", nil},
+		{"broken", "", errBackend},
+		{"partial", "stale", ErrTransient},
+		{"missing", "", ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			value, err := svc.GetData(ctx, tt.key)
+			if value != tt.value {
+				t.Errorf("Expected value %q, got %q", tt.value, value)
+			}
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	keys, err := svc.ListKeys(ctx)
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+	if want := []string{"broken", "partial", "user:1", "user:2"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Expected keys %v, got %v", want, keys)
+	}
+}
+
+func TestCannedServiceIgnoresWrites(t *testing.T) {
+	ctx := context.Background()
+	svc := NewCannedService(map[string]CannedResponse{"key": {Value: "canned"}})
+
+	if err := svc.PutData(ctx, "key", "overwritten"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	if err := svc.DeleteData(ctx, "key"); err != nil {
+		t.Fatalf("DeleteData failed: %v", err)
+	}
+	if got, err := svc.GetData(ctx, "key"); err != nil || got != "canned" {
+		t.Errorf("Expected the canned value to be unchanged, got %q (%v)", got, err)
+	}
+}
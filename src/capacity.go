@@ -0,0 +1,103 @@
+package main
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+)
+
+// EvictionPolicy decides what happens when a write would take a service
+// past its MaxKeys.
+type EvictionPolicy int
+
+const (
+	// EvictReject fails the write with ErrCapacityFull.
+	EvictReject EvictionPolicy = iota
+	// EvictFIFO evicts the key that was written first.
+	EvictFIFO
+	// EvictLRU evicts the key that was least recently read or written.
+	EvictLRU
+)
+
+func (p EvictionPolicy) String() string {
+	switch p {
+	case EvictReject:
+		return "reject"
+	case EvictFIFO:
+		return "fifo"
+	case EvictLRU:
+		return "lru"
+	}
+	return fmt.Sprintf("EvictionPolicy(%d)", int(p))
+}
+
+// track records a write of storage key k for eviction. Callers must hold
+// m.mu.
+func (m *MockService) track(k string) {
+	if m.MaxKeys <= 0 {
+		return
+	}
+	if _, ok := m.usage[k]; ok {
+		m.used(k)
+		return
+	}
+	if m.usage == nil {
+		m.usage = make(map[string]*list.Element)
+	}
+	m.usage[k] = m.usageOrder.PushBack(k)
+}
+
+// used records an access to storage key k, which under EvictLRU moves it
+// to the back of the eviction order. Callers must hold m.mu.
+func (m *MockService) used(k string) {
+	if e, ok := m.usage[k]; ok && m.Eviction == EvictLRU {
+		m.usageOrder.MoveToBack(e)
+	}
+}
+
+// makeRoom ensures storage key k can be written without exceeding
+// MaxKeys, evicting keys if the policy allows. Overwriting a key already
+// held never needs room. Callers must hold m.mu.
+func (m *MockService) makeRoom(k string) error {
+	if m.MaxKeys <= 0 {
+		return nil
+	}
+	if _, ok := m.usage[k]; ok {
+		return nil
+	}
+	for m.usageOrder.Len() >= m.MaxKeys {
+		if m.Eviction == EvictReject {
+			return fmt.Errorf("%d key limit reached: %w", m.MaxKeys, ErrCapacityFull)
+		}
+		victim := m.usageOrder.Front().Value.(string)
+		if err := m.store.Delete(victim); err != nil && !errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("failed to evict %s: %w", victim, err)
+		}
+		m.forget(victim)
+	}
+	return nil
+}
+
+// checkRoom returns ErrCapacityFull if writing the keys in puts, after
+// deleting those in deletes, would take the service past MaxKeys under
+// EvictReject. Callers must hold m.mu.
+func (m *MockService) checkRoom(puts, deletes []string) error {
+	if m.MaxKeys <= 0 || m.Eviction != EvictReject {
+		return nil
+	}
+	n := m.usageOrder.Len()
+	for _, key := range deletes {
+		if _, ok := m.usage[m.storageKey(key)]; ok {
+			n--
+		}
+	}
+	for _, key := range puts {
+		if _, ok := m.usage[m.storageKey(key)]; !ok {
+			n++
+		}
+	}
+	if n > m.MaxKeys {
+		return fmt.Errorf("%d keys would exceed the %d key limit: %w", n, m.MaxKeys, ErrCapacityFull)
+	}
+	return nil
+}
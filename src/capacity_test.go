@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestMaxKeysReject(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Bounded", WithMaxKeys(3, EvictReject))
+
+	for i := 0; i < 3; i++ {
+		if err := svc.PutData(ctx, fmt.Sprintf("key-%d", i), "value"); err != nil {
+			t.Fatalf("PutData %d failed: %v", i, err)
+		}
+	}
+	if err := svc.PutData(ctx, "key-3", "value"); !errors.Is(err, ErrCapacityFull) {
+		t.Fatalf("Expected ErrCapacityFull at the limit, got %v", err)
+	}
+	if ok, _ := svc.Exists(ctx, "key-3"); ok {
+		t.Error("Expected the rejected key not to be stored")
+	}
+
+	// Overwrites need no room, and deletes free some up.
+	if err := svc.PutData(ctx, "key-0", "updated"); err != nil {
+		t.Errorf("Expected an overwrite at the limit to succeed, got %v", err)
+	}
+	if err := svc.DeleteData(ctx, "key-1"); err != nil {
+		t.Fatalf("DeleteData failed: %v", err)
+	}
+	if err := svc.PutData(ctx, "key-3", "value"); err != nil {
+		t.Errorf("Expected a write after a delete to succeed, got %v", err)
+	}
+}
+
+func TestMaxKeysLRU(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Cache", WithMaxKeys(3, EvictLRU))
+
+	for _, k := range []string{"a", "b", "c"} {
+		if err := svc.PutData(ctx, k, k); err != nil {
+			t.Fatalf("PutData failed: %v", err)
+		}
+	}
+	// Reading a makes b the least recently used.
+	if _, err := svc.GetData(ctx, "a"); err != nil {
+		t.Fatalf("GetData failed: %v", err)
+	}
+	if err := svc.PutData(ctx, "d", "d"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+
+	keys, err := svc.ListKeys(ctx)
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+	if want := []string{"a", "c", "d"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Expected %v after evicting b, got %v", want, keys)
+	}
+}
+
+func TestLRUAccessPatterns(t *testing.T) {
+	tests := []struct {
+		name    string
+		ops     []string // "put:k" or "get:k", run in order
+		evicted []string
+		kept    []string
+	}{
+		{
+			name:    "reads refresh recency",
+			ops:     []string{"put:a", "put:b", "put:c", "get:a", "get:b", "put:d"},
+			evicted: []string{"c"},
+			kept:    []string{"a", "b", "d"},
+		},
+		{
+			name:    "overwrites refresh recency",
+			ops:     []string{"put:a", "put:b", "put:c", "put:a", "put:d"},
+			evicted: []string{"b"},
+			kept:    []string{"a", "c", "d"},
+		},
+		{
+			name:    "successive evictions follow access order",
+			ops:     []string{"put:a", "put:b", "put:c", "get:b", "get:a", "put:d", "put:e"},
+			evicted: []string{"c", "b"},
+			kept:    []string{"a", "d", "e"},
+		},
+		{
+			name:    "hot key survives churn",
+			ops:     []string{"put:hot", "put:x", "put:y", "get:hot", "put:z", "get:hot", "put:w"},
+			evicted: []string{"x", "y"},
+			kept:    []string{"hot", "z", "w"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc := NewMockService("Cache", WithMaxKeys(3, EvictLRU))
+			for _, op := range tt.ops {
+				kind, key := op[:3], op[4:]
+				var err error
+				if kind == "put" {
+					err = svc.PutData(ctx, key, key)
+				} else {
+					_, err = svc.GetData(ctx, key)
+				}
+				if err != nil {
+					t.Fatalf("%s failed: %v", op, err)
+				}
+			}
+			for _, k := range tt.evicted {
+				if _, err := svc.GetData(ctx, k); !errors.Is(err, ErrNotFound) {
+					t.Errorf("Expected %s to be evicted, got %v", k, err)
+				}
+			}
+			for _, k := range tt.kept {
+				if _, err := svc.GetData(ctx, k); err != nil {
+					t.Errorf("Expected %s to survive, got %v", k, err)
+				}
+			}
+		})
+	}
+}
+
+func TestMaxKeysFIFO(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Queue", WithMaxKeys(2, EvictFIFO))
+
+	for _, k := range []string{"a", "b"} {
+		if err := svc.PutData(ctx, k, k); err != nil {
+			t.Fatalf("PutData failed: %v", err)
+		}
+	}
+	// Reads do not protect a key under FIFO.
+	if _, err := svc.GetData(ctx, "a"); err != nil {
+		t.Fatalf("GetData failed: %v", err)
+	}
+	if err := svc.PutData(ctx, "c", "c"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	if _, err := svc.GetData(ctx, "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the first key written to be evicted, got %v", err)
+	}
+}
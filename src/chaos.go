@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ChaosConfig controls the faults a ChaosService injects on top of the
+// wrapped service's own behavior.
+type ChaosConfig struct {
+	// SpikeProbability is the chance, per call, of delaying the call by
+	// SpikeDuration before it reaches the wrapped service.
+	SpikeProbability float64
+	SpikeDuration    time.Duration
+
+	// OutageProbability is the chance, per call, of the service going down
+	// for OutageDuration. The call that starts an outage and every call
+	// during it fail with ErrUnavailable.
+	OutageProbability float64
+	OutageDuration    time.Duration
+
+	// Seed seeds the random source so a chaos run can be reproduced.
+	Seed int64
+}
+
+// ChaosService wraps an ExternalService and injects latency spikes and
+// outage windows for resilience testing.
+type ChaosService struct {
+	next ExternalService
+	cfg  ChaosConfig
+
+	mu          sync.Mutex
+	rng         *rand.Rand
+	outageUntil time.Time
+}
+
+// NewChaosService wraps next with the faults described by cfg.
+func NewChaosService(next ExternalService, cfg ChaosConfig) *ChaosService {
+	return &ChaosService{
+		next: next,
+		cfg:  cfg,
+		rng:  rand.New(rand.NewSource(cfg.Seed)),
+	}
+}
+
+// WithChaos wraps a service in a ChaosService configured by cfg.
+func WithChaos(cfg ChaosConfig) Decorator {
+	return func(next ExternalService) ExternalService {
+		return NewChaosService(next, cfg)
+	}
+}
+
+func (c *ChaosService) Connect(ctx context.Context) error {
+	if err := c.inject(ctx); err != nil {
+		return err
+	}
+	return c.next.Connect(ctx)
+}
+
+func (c *ChaosService) Ping(ctx context.Context) error {
+	if err := c.inject(ctx); err != nil {
+		return err
+	}
+	return c.next.Ping(ctx)
+}
+
+func (c *ChaosService) GetData(ctx context.Context, key string) (string, error) {
+	if err := c.inject(ctx); err != nil {
+		return "", err
+	}
+	return c.next.GetData(ctx, key)
+}
+
+func (c *ChaosService) PutData(ctx context.Context, key string, value string) error {
+	if err := c.inject(ctx); err != nil {
+		return err
+	}
+	return c.next.PutData(ctx, key, value)
+}
+
+func (c *ChaosService) DeleteData(ctx context.Context, key string) error {
+	if err := c.inject(ctx); err != nil {
+		return err
+	}
+	return c.next.DeleteData(ctx, key)
+}
+
+func (c *ChaosService) ListKeys(ctx context.Context) ([]string, error) {
+	if err := c.inject(ctx); err != nil {
+		return nil, err
+	}
+	return c.next.ListKeys(ctx)
+}
+
+// inject applies this call's faults. It fails the call during an outage,
+// possibly starts a new outage, and otherwise possibly delays the call by a
+// latency spike.
+func (c *ChaosService) inject(ctx context.Context) error {
+	c.mu.Lock()
+	now := time.Now()
+	if now.Before(c.outageUntil) {
+		until := c.outageUntil
+		c.mu.Unlock()
+		return fmt.Errorf("chaos outage until %s: %w", until.Format(time.RFC3339Nano), ErrUnavailable)
+	}
+	if c.rng.Float64() < c.cfg.OutageProbability {
+		c.outageUntil = now.Add(c.cfg.OutageDuration)
+		c.mu.Unlock()
+		return fmt.Errorf("chaos outage started for %v: %w", c.cfg.OutageDuration, ErrUnavailable)
+	}
+	spike := c.rng.Float64() < c.cfg.SpikeProbability
+	c.mu.Unlock()
+
+	if !spike {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(c.cfg.SpikeDuration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestChaosOutageWindow(t *testing.T) {
+	ctx := context.Background()
+	cfg := ChaosConfig{
+		OutageProbability: 0.2,
+		OutageDuration:    30 * time.Millisecond,
+		Seed:              7,
+	}
+	svc := NewChaosService(NewMockService("Chaotic"), cfg)
+
+	// Replay the service's random draws to find the call that starts the
+	// first outage: each call outside an outage draws once for the outage
+	// and once for the latency spike.
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	first := 0
+	for rng.Float64() >= cfg.OutageProbability {
+		rng.Float64()
+		first++
+	}
+
+	for i := 0; i < first; i++ {
+		if err := svc.Ping(ctx); err != nil {
+			t.Fatalf("Call %d: expected success before the outage, got %v", i, err)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		err := svc.Ping(ctx)
+		if !errors.Is(err, ErrUnavailable) {
+			t.Fatalf("Outage call %d: expected ErrUnavailable, got %v", i, err)
+		}
+		if !IsRetryable(err) {
+			t.Errorf("Expected outage errors to be retryable, got %v", err)
+		}
+	}
+
+	time.Sleep(cfg.OutageDuration)
+	recovered := rng.Float64() >= cfg.OutageProbability
+	if err := svc.Ping(ctx); recovered && err != nil {
+		t.Errorf("Expected the service to recover after the outage, got %v", err)
+	} else if !recovered && !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected a new outage, got %v", err)
+	}
+}
+
+func TestChaosIsReproducible(t *testing.T) {
+	ctx := context.Background()
+	cfg := ChaosConfig{OutageProbability: 0.3, Seed: 42}
+	pattern := func() []bool {
+		svc := NewChaosService(NewMockService("Chaotic"), cfg)
+		var out []bool
+		for i := 0; i < 50; i++ {
+			out = append(out, svc.Ping(ctx) != nil)
+		}
+		return out
+	}
+
+	a, b := pattern(), pattern()
+	failures := 0
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("Call %d differs between runs with the same seed", i)
+		}
+		if a[i] {
+			failures++
+		}
+	}
+	if failures == 0 || failures == len(a) {
+		t.Errorf("Expected a mix of failures and successes, got %d of %d failed", failures, len(a))
+	}
+}
+
+func TestChaosLatencySpike(t *testing.T) {
+	cfg := ChaosConfig{SpikeProbability: 1, SpikeDuration: 20 * time.Millisecond}
+	svc := Chain(NewMockService("Spiky"), WithChaos(cfg))
+
+	start := time.Now()
+	if err := svc.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < cfg.SpikeDuration {
+		t.Errorf("Expected a latency spike of at least %v, took %v", cfg.SpikeDuration, elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := svc.Ping(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the spike to honor the context deadline, got %v", err)
+	}
+}
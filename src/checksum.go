@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+// PutDataChecksummed stores data in the mock service like PutData, along
+// with the SHA-256 checksum of value for GetDataVerified to check.
+func (m *MockService) PutDataChecksummed(ctx context.Context, key string, value string) error {
+	return m.write(ctx, "PutDataChecksummed", key, value, func(k string) {
+		if m.checksums == nil {
+			m.checksums = make(map[string]string)
+		}
+		m.checksums[k] = checksum(value)
+	})
+}
+
+// GetDataVerified retrieves data from the mock service like GetData, then
+// checks it against the checksum stored by PutDataChecksummed. It returns
+// ErrIntegrity if the value read does not match, and ErrNoChecksum if key
+// was not written with a checksum.
+func (m *MockService) GetDataVerified(ctx context.Context, key string) (string, error) {
+	return m.read(ctx, "GetDataVerified", key, func(k, val string) error {
+		want, ok := m.checksums[k]
+		if !ok {
+			return fmt.Errorf("key %s has no checksum to verify: %w", key, ErrNoChecksum)
+		}
+		if got := checksum(val); got != want {
+			return fmt.Errorf("key %s checksum %s does not match %s: %w", key, got, want, ErrIntegrity)
+		}
+		return nil
+	})
+}
+
+// CorruptKey flips a bit of the value stored under key, bypassing the
+// service, to simulate damage at rest. Reads of key then fail with
+// ErrCorrupted until it is written again. It returns ErrNotFound if key
+// does not exist.
+func (m *MockService) CorruptKey(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := m.storageKey(key)
+	val, err := m.store.Get(k)
+	if errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("key %s %w", key, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s from %s: %w", key, m.name, err)
+	}
+	if err := m.store.Set(k, m.corrupt(val)); err != nil {
+		return fmt.Errorf("failed to write %s to %s: %w", key, m.name, err)
+	}
+	if m.corrupted == nil {
+		m.corrupted = make(map[string]bool)
+	}
+	m.corrupted[k] = true
+	return nil
+}
+
+// verify checks val, read from the store under key, against the CRC32
+// recorded when it was written. Only keys damaged by CorruptKey are
+// checked, since another service sharing the store may legitimately have
+// rewritten the others. A damaged key with no recorded CRC32 cannot be
+// checked, so it fails as corrupted. Callers must hold m.mu.
+func (m *MockService) verify(key, val string) error {
+	k := m.storageKey(key)
+	if !m.corrupted[k] {
+		return nil
+	}
+	md, ok := m.meta[k]
+	if !ok {
+		return fmt.Errorf("key %s was damaged and has no checksum: %w", key, ErrCorrupted)
+	}
+	if got := crc32.ChecksumIEEE([]byte(val)); got != md.CRC32 {
+		return fmt.Errorf("key %s CRC32 %08x does not match %08x: %w", key, got, md.CRC32, ErrCorrupted)
+	}
+	return nil
+}
+
+// checksum returns the hex-encoded SHA-256 digest of value.
+func checksum(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
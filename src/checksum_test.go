@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestChecksummedRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Verified")
+
+	if err := svc.PutDataChecksummed(ctx, "file", "contents"); err != nil {
+		t.Fatalf("PutDataChecksummed failed: %v", err)
+	}
+	got, err := svc.GetDataVerified(ctx, "file")
+	if err != nil {
+		t.Fatalf("GetDataVerified failed: %v", err)
+	}
+	if got != "contents" {
+		t.Errorf("Expected %q, got %q", "contents", got)
+	}
+}
+
+func TestGetDataVerifiedDetectsCorruption(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Rotting", WithCorruptionRate(1.0))
+
+	if err := svc.PutDataChecksummed(ctx, "file", "contents"); err != nil {
+		t.Fatalf("PutDataChecksummed failed: %v", err)
+	}
+	got, err := svc.GetDataVerified(ctx, "file")
+	if !errors.Is(err, ErrIntegrity) {
+		t.Fatalf("Expected ErrIntegrity, got %v", err)
+	}
+	if got != "" {
+		t.Errorf("Expected no value on an integrity failure, got %q", got)
+	}
+	if !IsRetryable(err) {
+		t.Errorf("Expected an integrity failure to be retryable, got %v", err)
+	}
+}
+
+func TestGetDataVerifiedRequiresChecksum(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Verified")
+
+	if err := svc.PutDataChecksummed(ctx, "file", "contents"); err != nil {
+		t.Fatalf("PutDataChecksummed failed: %v", err)
+	}
+	// A plain overwrite replaces the value without a checksum.
+	if err := svc.PutData(ctx, "file", "unverified"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	if _, err := svc.GetDataVerified(ctx, "file"); !errors.Is(err, ErrNoChecksum) || IsRetryable(err) {
+		t.Errorf("Expected a permanent ErrNoChecksum without a checksum, got %v", err)
+	}
+	if _, err := svc.GetDataVerified(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestCorruptKey(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Disk")
+	if err := svc.PutData(ctx, "intact", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.PutData(ctx, "damaged", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := svc.GetData(ctx, "intact"); err != nil || got != "value" {
+		t.Fatalf("Expected a clean round trip, got %q, %v", got, err)
+	}
+
+	if err := svc.CorruptKey("damaged"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := svc.GetData(ctx, "damaged")
+	if !errors.Is(err, ErrCorrupted) || IsRetryable(err) {
+		t.Fatalf("Expected a permanent ErrCorrupted, got %v", err)
+	}
+	if _, err := svc.GetData(ctx, "intact"); err != nil {
+		t.Errorf("Expected other keys to read cleanly, got %v", err)
+	}
+
+	// Rewriting the key repairs it.
+	if err := svc.PutData(ctx, "damaged", "fresh"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := svc.GetData(ctx, "damaged"); err != nil || got != "fresh" {
+		t.Errorf("Expected the rewritten value, got %q, %v", got, err)
+	}
+
+	if err := svc.CorruptKey("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestCorruptKeyWithoutChecksum(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	writer := NewMockService("Writer", WithStore(store))
+	if err := writer.PutData(ctx, "shared", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	// reader shares the store but never wrote the key, so it has no CRC32.
+	reader := NewMockService("Reader", WithStore(store))
+	if err := reader.CorruptKey("shared"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reader.GetData(ctx, "shared"); !errors.Is(err, ErrCorrupted) {
+		t.Errorf("Expected ErrCorrupted, got %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var clockEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFakeClock(t *testing.T) {
+	clock := NewFakeClock(clockEpoch)
+	soon := clock.After(time.Second)
+	later := clock.After(time.Minute)
+
+	clock.Advance(time.Second)
+	select {
+	case got := <-soon:
+		if !got.Equal(clockEpoch.Add(time.Second)) {
+			t.Errorf("Expected to fire at %v, got %v", clockEpoch.Add(time.Second), got)
+		}
+	default:
+		t.Fatal("Expected After(1s) to fire after advancing 1s")
+	}
+	select {
+	case <-later:
+		t.Fatal("Expected After(1m) not to fire yet")
+	default:
+	}
+	if n := clock.Waiters(); n != 1 {
+		t.Errorf("Expected 1 pending waiter, got %d", n)
+	}
+
+	clock.Advance(time.Hour)
+	select {
+	case <-later:
+	default:
+		t.Fatal("Expected After(1m) to fire after advancing past it")
+	}
+	if got := clock.Now(); !got.Equal(clockEpoch.Add(time.Hour + time.Second)) {
+		t.Errorf("Unexpected Now: %v", got)
+	}
+}
+
+func TestTTLExpiresWithFakeClock(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(clockEpoch)
+	svc := NewMockService("Expiring", WithClock(clock))
+
+	if err := svc.PutDataWithTTL(ctx, "session", "token", time.Hour); err != nil {
+		t.Fatalf("PutDataWithTTL failed: %v", err)
+	}
+	if err := svc.PutData(ctx, "permanent", "value"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+
+	clock.Advance(59 * time.Minute)
+	if got, err := svc.GetData(ctx, "session"); err != nil || got != "token" {
+		t.Fatalf("Expected the key to live until its TTL, got %q (%v)", got, err)
+	}
+
+	clock.Advance(time.Minute)
+	if _, err := svc.GetData(ctx, "session"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound once the TTL passed, got %v", err)
+	}
+	keys, err := svc.ListKeys(ctx)
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "permanent" {
+		t.Errorf("Expected only the permanent key to remain, got %v", keys)
+	}
+}
+
+func TestPutDataClearsTTL(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(clockEpoch)
+	svc := NewMockService("Expiring", WithClock(clock))
+
+	if err := svc.PutDataWithTTL(ctx, "key", "temporary", time.Minute); err != nil {
+		t.Fatalf("PutDataWithTTL failed: %v", err)
+	}
+	if err := svc.PutData(ctx, "key", "kept"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	clock.Advance(time.Hour)
+	if got, err := svc.GetData(ctx, "key"); err != nil || got != "kept" {
+		t.Errorf("Expected PutData to clear the TTL, got %q (%v)", got, err)
+	}
+}
+
+func TestMockServiceLatencyUsesClock(t *testing.T) {
+	clock := NewFakeClock(clockEpoch)
+	svc := NewMockService("Slow", WithClock(clock), WithResponseTime(time.Hour))
+
+	var dur time.Duration
+	svc.OnOperation = func(_, _ string, d time.Duration, _ error) { dur = d }
+
+	done := make(chan error, 1)
+	go func() { done <- svc.Ping(context.Background()) }()
+
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Hour)
+	if err := <-done; err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if dur != time.Hour {
+		t.Errorf("Expected the operation to take 1h of fake time, got %v", dur)
+	}
+}
+
+func TestMetadataTimestampsUseClock(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(clockEpoch)
+	svc := NewMockService("Objects", WithClock(clock))
+
+	if err := svc.PutData(ctx, "object", "v1"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	clock.Advance(time.Minute)
+	if err := svc.PutData(ctx, "object", "v2"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	_, md, err := svc.GetDataWithMetadata(ctx, "object")
+	if err != nil {
+		t.Fatalf("GetDataWithMetadata failed: %v", err)
+	}
+	if !md.CreatedAt.Equal(clockEpoch) || !md.ModifiedAt.Equal(clockEpoch.Add(time.Minute)) {
+		t.Errorf("Unexpected timestamps: created %v, modified %v", md.CreatedAt, md.ModifiedAt)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// VerifyConsistency cross-checks the service's bookkeeping against its
+// store: the keys in the service's namespace must be exactly those written
+// through it and not since deleted, evicted or expired. It returns an
+// error describing any disagreement, which points at a feature that
+// changes the store without going through save and remove, or at another
+// writer sharing the store and namespace.
+func (m *MockService) VerifyConsistency() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, err := m.store.Keys()
+	if err != nil {
+		return fmt.Errorf("failed to list keys from %s: %w", m.name, err)
+	}
+	var untracked []string
+	count := 0
+	for _, k := range stored {
+		key, ok := m.publicKey(k)
+		if !ok {
+			continue
+		}
+		count++
+		if _, ok := m.meta[k]; !ok {
+			untracked = append(untracked, key)
+		}
+	}
+
+	if count != m.liveKeys || len(untracked) > 0 {
+		sort.Strings(untracked)
+		return fmt.Errorf("%s is inconsistent: store holds %d keys but %d were written and not removed (untracked keys: %v)",
+			m.name, count, m.liveKeys, untracked)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyConsistency(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(clockEpoch)
+	svc := NewMockService("Checked", WithDryRun(true), WithClock(clock), WithMaxKeys(4, EvictFIFO))
+
+	for i := 0; i < 5; i++ {
+		if err := svc.PutData(ctx, fmt.Sprintf("key-%d", i), "value"); err != nil {
+			t.Fatalf("PutData %d failed: %v", i, err)
+		}
+	}
+	if err := svc.PutData(ctx, "key-4", "overwritten"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	if err := svc.DeleteData(ctx, "key-1"); err != nil {
+		t.Fatalf("DeleteData failed: %v", err)
+	}
+	if err := svc.DeleteData(ctx, "missing"); err == nil {
+		t.Fatal("Expected deleting a missing key to fail")
+	}
+	if err := svc.PutDataWithTTL(ctx, "short-lived", "value", time.Second); err != nil {
+		t.Fatalf("PutDataWithTTL failed: %v", err)
+	}
+	clock.Advance(2 * time.Second)
+
+	if err := svc.VerifyConsistency(); err != nil {
+		t.Errorf("Expected a consistent service, got %v", err)
+	}
+	keys, err := svc.ListKeys(ctx)
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+	if len(keys) != 3 {
+		t.Errorf("Expected 3 keys, got %v", keys)
+	}
+}
+
+func TestVerifyConsistencyDetectsStrayKeys(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	svc := NewMockService("Checked", WithDryRun(true), WithStore(store), WithNamespace("app"))
+
+	if err := svc.PutData(ctx, "tracked", "value"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	// Keys outside the namespace are not the service's concern.
+	if err := store.Set("other:key", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.VerifyConsistency(); err != nil {
+		t.Fatalf("Expected a consistent service, got %v", err)
+	}
+
+	// A write that bypasses the service's bookkeeping is caught.
+	if err := store.Set("app:stray", "value"); err != nil {
+		t.Fatal(err)
+	}
+	err := svc.VerifyConsistency()
+	if err == nil {
+		t.Fatal("Expected an inconsistency for a key written behind the service's back")
+	}
+	if !strings.Contains(err.Error(), "stray") {
+		t.Errorf("Expected the error to name the stray key, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+)
+
+// contextKey is the type of context keys defined by this package, which
+// prevents collisions with keys defined elsewhere.
+type contextKey int
+
+const (
+	traceIDKey contextKey = iota
+	requestIDKey
+	tokenKey
+)
+
+// ContextWithTraceID returns a copy of ctx carrying the given trace ID.
+// MockService includes it in the log entries of every operation.
+func ContextWithTraceID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, traceIDKey, id)
+}
+
+// TraceIDFromContext returns the trace ID stored in ctx, if any.
+func TraceIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(traceIDKey).(string)
+	return id, ok && id != ""
+}
+
+// ContextWithRequestID returns a copy of ctx carrying the given request ID.
+// MockService prefixes the errors of every operation with it and attaches
+// it to their log records so that output from concurrent requests can be
+// told apart.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey).(string)
+	return id, ok && id != ""
+}
+
+// ContextWithToken returns a copy of ctx carrying an access token issued by
+// an AuthService. Calls made with it are authorized by that token.
+func ContextWithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenKey, token)
+}
+
+// TokenFromContext returns the access token stored in ctx, if any.
+func TokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(tokenKey).(string)
+	return token, ok && token != ""
+}
+
+// contextAttrs returns log attributes for the trace and request IDs stored
+// in ctx, if any.
+func contextAttrs(ctx context.Context) []slog.Attr {
+	var attrs []slog.Attr
+	if id, ok := TraceIDFromContext(ctx); ok {
+		attrs = append(attrs, slog.String("trace_id", id))
+	}
+	if id, ok := RequestIDFromContext(ctx); ok {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	return attrs
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestTraceIDInOperationLog(t *testing.T) {
+	var buf bytes.Buffer
+	svc := NewBasicMockService("Traced", 0, 0)
+	svc.Logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	ctx := ContextWithTraceID(context.Background(), "trace-123")
+	if err := svc.PutData(ctx, "key", "value"); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, err := svc.GetData(ctx, "key"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if _, err := svc.GetData(context.Background(), "key"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 log entries, got %d:\n%s", len(lines), buf.String())
+	}
+	for i, line := range lines[:2] {
+		if !strings.Contains(line, "trace_id=trace-123") {
+			t.Errorf("Entry %d missing trace ID: %s", i, line)
+		}
+	}
+	if strings.Contains(lines[2], "trace_id=") {
+		t.Errorf("Entry without a traced context should not carry a trace ID: %s", lines[2])
+	}
+}
+
+func TestTraceIDFromContext(t *testing.T) {
+	if _, ok := TraceIDFromContext(context.Background()); ok {
+		t.Error("Expected no trace ID on a bare context")
+	}
+	id, ok := TraceIDFromContext(ContextWithTraceID(context.Background(), "abc"))
+	if !ok || id != "abc" {
+		t.Errorf("Expected trace ID %q, got %q (ok=%v)", "abc", id, ok)
+	}
+}
+
+func TestRequestIDInErrorsAndLogs(t *testing.T) {
+	var buf bytes.Buffer
+	svc := NewMockService("Requested", WithFailureRate(1.0), WithLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+
+	ctx := ContextWithRequestID(context.Background(), "req-42")
+	_, err := svc.GetData(ctx, "key")
+	if err == nil {
+		t.Fatal("Expected GetData to fail")
+	}
+	if !strings.HasPrefix(err.Error(), "[req-42] ") {
+		t.Errorf("Expected error to be prefixed with the request ID, got %q", err)
+	}
+	if !errors.Is(err, ErrTransient) {
+		t.Errorf("Expected the prefixed error to still be transient, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "request_id=req-42") {
+		t.Errorf("Expected log entry to carry the request ID, got %q", buf.String())
+	}
+
+	if _, err := svc.GetData(context.Background(), "key"); strings.HasPrefix(err.Error(), "[") {
+		t.Errorf("Expected no prefix without a request ID, got %q", err)
+	}
+}
+
+func TestRequestIDFromContext(t *testing.T) {
+	if _, ok := RequestIDFromContext(context.Background()); ok {
+		t.Error("Expected no request ID on a bare context")
+	}
+	id, ok := RequestIDFromContext(ContextWithRequestID(context.Background(), "abc"))
+	if !ok || id != "abc" {
+		t.Errorf("Expected request ID %q, got %q (ok=%v)", "abc", id, ok)
+	}
+}
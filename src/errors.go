@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error classes. Every error a MockService produces matches exactly one of
+// them with errors.Is, which lets callers decide whether to retry.
+var (
+	// ErrTransient marks failures that may succeed if retried, such as
+	// dropped connections and simulated outages.
+	ErrTransient = errors.New("transient error")
+
+	// ErrPermanent marks failures that will keep failing if retried, such
+	// as missing keys and rejected values.
+	ErrPermanent = errors.New("permanent error")
+)
+
+var (
+	// ErrNotFound is returned when a requested key does not exist. It is
+	// permanent.
+	ErrNotFound error = &classifiedError{"not found", ErrPermanent}
+
+	// ErrValueTooLarge is returned when a value exceeds the service's
+	// MaxValueSize. It is permanent.
+	ErrValueTooLarge error = &classifiedError{"value too large", ErrPermanent}
+
+	// ErrInvalidKey is returned when a key breaks the service's key naming
+	// rules. It is permanent.
+	ErrInvalidKey error = &classifiedError{"invalid key", ErrPermanent}
+
+	// ErrCapacityFull is returned when a write would take a service past
+	// its MaxKeys and its eviction policy is EvictReject. It is permanent.
+	ErrCapacityFull error = &classifiedError{"capacity full", ErrPermanent}
+
+	// ErrIntegrity is returned when a value read back does not match the
+	// checksum it was written with. It is transient: the corruption
+	// happened on the read, so reading again may return the intact value.
+	ErrIntegrity error = &classifiedError{"integrity check failed", ErrTransient}
+
+	// ErrNoChecksum is returned when a value is read with verification but
+	// was not written with a checksum. It is permanent: reading again
+	// cannot produce one.
+	ErrNoChecksum error = &classifiedError{"no checksum", ErrPermanent}
+
+	// ErrCorrupted is returned when a stored value no longer matches the
+	// CRC-32 it was written with. It is permanent: the stored bytes are
+	// damaged, so reading again returns the same value.
+	ErrCorrupted error = &classifiedError{"stored value corrupted", ErrPermanent}
+
+	// ErrPoolExhausted is returned when no pooled connection frees up
+	// within a service's PoolTimeout. It is transient.
+	ErrPoolExhausted error = &classifiedError{"connection pool exhausted", ErrTransient}
+
+	// ErrBusy is returned when a service already processes as many
+	// operations as it allows and RejectWhenBusy is set. It is transient.
+	ErrBusy error = &classifiedError{"service busy", ErrTransient}
+
+	// ErrRateLimited is returned when an operation exceeds the service's
+	// RateLimit. It is transient.
+	ErrRateLimited error = &classifiedError{"rate limit exceeded", ErrTransient}
+
+	// ErrUnavailable is returned while a service is down, such as during a
+	// ChaosService outage window. It is transient.
+	ErrUnavailable error = &classifiedError{"service unavailable", ErrTransient}
+
+	// ErrConnectionLost is returned by a MockService whose connection was
+	// dropped with Disconnect. It is transient: the operation may succeed
+	// once the caller reconnects.
+	ErrConnectionLost error = &classifiedError{"connection lost", ErrTransient}
+
+	// ErrNotConnected is returned by a StrictConnect service for operations
+	// made before Connect succeeds or after Close. It is permanent: the
+	// caller must connect first.
+	ErrNotConnected error = &classifiedError{"not connected", ErrPermanent}
+
+	// ErrNotReady is returned by Connect while a service is still starting
+	// up, within its ReadinessDelay. It is transient.
+	ErrNotReady error = &classifiedError{"service not ready", ErrTransient}
+
+	// ErrUnauthorized is returned when a call to an AuthService carries no
+	// valid token. It is permanent: retrying will not help until the
+	// caller authenticates again.
+	ErrUnauthorized error = &classifiedError{"unauthorized", ErrPermanent}
+
+	// ErrTokenExpired is returned when a call to an AuthService carries a
+	// token that has expired. It matches ErrUnauthorized, and tells the
+	// caller to refresh its token rather than fix its credentials.
+	ErrTokenExpired = fmt.Errorf("token expired: %w", ErrUnauthorized)
+)
+
+// classifiedError is a sentinel error that also matches its class.
+type classifiedError struct {
+	msg   string
+	class error
+}
+
+func (e *classifiedError) Error() string { return e.msg }
+
+func (e *classifiedError) Is(target error) bool { return target == e.class }
+
+// ErrorFormatter builds the error a MockService returns from a failed
+// operation. It is called with the operation name, such as "GetData", the
+// service name and cause, the error the service would return by default.
+// The result should wrap cause so that errors.Is and IsRetryable still see
+// its sentinel.
+type ErrorFormatter func(op, name string, cause error) error
+
+// IsRetryable reports whether err is worth retrying. Transient errors are
+// retryable; permanent errors, context errors and unclassified errors are
+// not. Errors outside this package can opt in by implementing
+// Retryable() bool.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var r interface{ Retryable() bool }
+	if errors.As(err, &r) {
+		return r.Retryable()
+	}
+	return errors.Is(err, ErrTransient)
+}
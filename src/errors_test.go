@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestErrorClassification(t *testing.T) {
+	ctx := context.Background()
+	failing := NewMockService("Failing", WithFailureRate(1.0))
+	db := newTestDatabase(t)
+	db.failureRate = 1.0
+	healthy := NewMockService("Healthy", WithMaxValueSize(4))
+
+	tests := []struct {
+		name      string
+		err       error
+		sentinel  error
+		retryable bool
+	}{
+		{"connect failure", failing.Connect(ctx), ErrTransient, true},
+		{"ping failure", failing.Ping(ctx), ErrTransient, true},
+		{"get failure", second(failing.GetData(ctx, "key")), ErrTransient, true},
+		{"exists failure", second(failing.Exists(ctx, "key")), ErrTransient, true},
+		{"put failure", failing.PutData(ctx, "key", "value"), ErrTransient, true},
+		{"delete failure", failing.DeleteData(ctx, "key"), ErrTransient, true},
+		{"delete prefix failure", second(failing.DeletePrefix(ctx, "key")), ErrTransient, true},
+		{"get multi failure", second(failing.GetMulti(ctx, []string{"key"})), ErrTransient, true},
+		{"list failure", second(failing.ListKeys(ctx)), ErrTransient, true},
+		{"begin failure", second(db.Begin(ctx)), ErrTransient, true},
+		{"not found", second(healthy.GetData(ctx, "missing")), ErrNotFound, false},
+		{"delete not found", healthy.DeleteData(ctx, "missing"), ErrNotFound, false},
+		{"value too large", healthy.PutData(ctx, "key", "too long"), ErrValueTooLarge, false},
+		{"invalid key", NewMockService("Strict", WithMaxKeyLength(1)).PutData(ctx, "key", "v"), ErrInvalidKey, false},
+		{"rate limited", fmt.Errorf("api: %w", ErrRateLimited), ErrRateLimited, true},
+		{"pool exhausted", fmt.Errorf("db: %w", ErrPoolExhausted), ErrPoolExhausted, true},
+		{"busy", fmt.Errorf("api: %w", ErrBusy), ErrBusy, true},
+		{"unavailable", fmt.Errorf("chaos: %w", ErrUnavailable), ErrUnavailable, true},
+		{"integrity", fmt.Errorf("read: %w", ErrIntegrity), ErrIntegrity, true},
+		{"no checksum", fmt.Errorf("read: %w", ErrNoChecksum), ErrNoChecksum, false},
+		{"corrupted", fmt.Errorf("read: %w", ErrCorrupted), ErrCorrupted, false},
+		{"tx done", fmt.Errorf("commit: %w", ErrTxDone), ErrTxDone, false},
+		{"capacity full", fmt.Errorf("cache: %w", ErrCapacityFull), ErrCapacityFull, false},
+		{"not ready", fmt.Errorf("db: %w", ErrNotReady), ErrNotReady, true},
+		{"not connected", NewMockService("Strict", WithStrictConnect(true)).Ping(ctx), ErrNotConnected, false},
+		{"connection lost", fmt.Errorf("db: %w", ErrConnectionLost), ErrConnectionLost, true},
+		{"unauthorized", fmt.Errorf("auth: %w", ErrUnauthorized), ErrUnauthorized, false},
+		{"token expired", fmt.Errorf("auth: %w", ErrTokenExpired), ErrUnauthorized, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !errors.Is(tt.err, tt.sentinel) {
+				t.Fatalf("Expected %v to match %v", tt.err, tt.sentinel)
+			}
+			if got := IsRetryable(tt.err); got != tt.retryable {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.retryable)
+			}
+			if tt.retryable == errors.Is(tt.err, ErrPermanent) {
+				t.Errorf("Error %v is classified both or neither transient and permanent", tt.err)
+			}
+		})
+	}
+}
+
+func TestIsRetryableEdgeCases(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"unclassified", errors.New("boom"), false},
+		{"canceled", context.Canceled, false},
+		{"deadline", context.DeadlineExceeded, false},
+		{"custom retryable", retryableError{true}, true},
+		{"custom permanent", fmt.Errorf("wrapped: %w", retryableError{false}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifiedErrorMessages(t *testing.T) {
+	_, err := NewMockService("Messages").GetData(context.Background(), "missing")
+	if !strings.Contains(err.Error(), "key missing not found") {
+		t.Errorf("Unexpected not-found message: %v", err)
+	}
+}
+
+func TestErrorFormatter(t *testing.T) {
+	ctx := context.Background()
+	var calls []string
+	format := func(op, name string, cause error) error {
+		calls = append(calls, op)
+		return fmt.Errorf("AwsError: %s (Service: %s, Region: eu-west-1): %w", op, name, cause)
+	}
+
+	svc := NewMockService("S3", WithErrorFormatter(format))
+	_, err := svc.GetData(ctx, "missing")
+	if want := "AwsError: GetData (Service: S3, Region: eu-west-1): key missing not found"; err == nil || err.Error() != want {
+		t.Errorf("Expected %q, got %v", want, err)
+	}
+	if !errors.Is(err, ErrNotFound) || IsRetryable(err) {
+		t.Errorf("Expected the formatted error to wrap ErrNotFound, got %v", err)
+	}
+
+	flaky := NewMockService("S3", WithFailureRate(1), WithErrorFormatter(format))
+	err = flaky.PutData(ctx, "key", "value")
+	if err == nil || !strings.HasPrefix(err.Error(), "AwsError: PutData (Service: S3") {
+		t.Errorf("Expected the custom text, got %v", err)
+	}
+	if !errors.Is(err, ErrTransient) || !IsRetryable(err) {
+		t.Errorf("Expected the formatted error to stay retryable, got %v", err)
+	}
+
+	// Successful operations are left alone, and request IDs still lead.
+	if err := svc.PutData(ctx, "key", "value"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	_, err = svc.GetData(ContextWithRequestID(ctx, "req-1"), "missing")
+	if err == nil || !strings.HasPrefix(err.Error(), "[req-1] AwsError: GetData") {
+		t.Errorf("Expected the request ID before the custom text, got %v", err)
+	}
+	if want := []string{"GetData", "PutData", "GetData"}; strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected the formatter to run for %v, got %v", want, calls)
+	}
+}
+
+type retryableError struct{ retryable bool }
+
+func (e retryableError) Error() string   { return "custom" }
+func (e retryableError) Retryable() bool { return e.retryable }
+
+// second returns the error from a two-value call.
+func second[T any](_ T, err error) error {
+	return err
+}
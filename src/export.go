@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// snapshot is the serialized form of a MockService's contents.
+type snapshot struct {
+	Entries map[string]string `json:"entries"`
+	// ExpiresAt holds when each entry written with a TTL expires.
+	ExpiresAt map[string]time.Time `json:"expiresAt,omitempty"`
+}
+
+// ExportJSON writes every key/value pair held by the service to w as JSON,
+// along with the expiry time of keys written with a TTL. Keys are written
+// without the service's namespace prefix.
+func (m *MockService) ExportJSON(w io.Writer) error {
+	m.mu.Lock()
+	snap, err := m.snapshot()
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(snap); err != nil {
+		return fmt.Errorf("failed to export data from %s: %w", m.name, err)
+	}
+	return nil
+}
+
+// ImportJSON reads data written by ExportJSON from r and stores every entry
+// in the service, overwriting existing keys of the same name and restoring
+// their expiry times. Entries are checked against the service's key and
+// value limits, and against MaxKeys under EvictReject, first; if any is
+// rejected, nothing is imported.
+func (m *MockService) ImportJSON(r io.Reader) error {
+	var snap snapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return fmt.Errorf("failed to import data into %s: %w", m.name, err)
+	}
+	keys := make([]string, 0, len(snap.Entries))
+	for k, v := range snap.Entries {
+		if err := m.checkWrite(k, v); err != nil {
+			return fmt.Errorf("failed to import data into %s: %w", m.name, err)
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkRoom(keys, nil); err != nil {
+		return fmt.Errorf("failed to import data into %s: %w", m.name, err)
+	}
+	for _, k := range keys {
+		if err := m.save(k, snap.Entries[k]); err != nil {
+			return err
+		}
+		if at, ok := snap.ExpiresAt[k]; ok {
+			if m.expiresAt == nil {
+				m.expiresAt = make(map[string]time.Time)
+			}
+			m.expiresAt[m.storageKey(k)] = at
+		}
+	}
+	return nil
+}
+
+// snapshot collects the service's contents, reading the store directly so
+// that exporting neither counts as a use of each key nor fails on values
+// damaged by CorruptKey. Callers must hold m.mu.
+func (m *MockService) snapshot() (snapshot, error) {
+	keys, err := m.keys()
+	if err != nil {
+		return snapshot{}, err
+	}
+	snap := snapshot{Entries: make(map[string]string, len(keys))}
+	for _, key := range keys {
+		val, err := m.store.Get(m.storageKey(key))
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return snapshot{}, fmt.Errorf("failed to export %s from %s: %w", key, m.name, err)
+		}
+		snap.Entries[key] = val
+		if at, ok := m.expiresAt[m.storageKey(key)]; ok {
+			if snap.ExpiresAt == nil {
+				snap.ExpiresAt = make(map[string]time.Time)
+			}
+			snap.ExpiresAt[key] = at
+		}
+	}
+	return snap, nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMockServiceJSONRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src := NewBasicMockService("Source", 0, 0)
+	src.Namespace = "src"
+
+	want := map[string]string{
+		"alpha":       "1",
+		"beta":        "two",
+		"nested/key":  `{"json": true}`,
+		"unicode-key": "héllo ✓",
+	}
+	for k, v := range want {
+		if err := src.PutData(ctx, k, v); err != nil {
+			t.Fatalf("Put %s failed: %v", k, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := src.ExportJSON(&buf); err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+
+	dst := NewBasicMockService("Destination", 0, 0)
+	if err := dst.ImportJSON(&buf); err != nil {
+		t.Fatalf("ImportJSON failed: %v", err)
+	}
+
+	keys, err := dst.ListKeys(ctx)
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+	if len(keys) != len(want) {
+		t.Errorf("Expected %d keys after import, got %d: %v", len(want), len(keys), keys)
+	}
+	for k, v := range want {
+		got, err := dst.GetData(ctx, k)
+		if err != nil {
+			t.Errorf("Get %s failed: %v", k, err)
+			continue
+		}
+		if got != v {
+			t.Errorf("Key %s: expected %q, got %q", k, v, got)
+		}
+	}
+}
+
+func TestMockServiceImportJSONInvalid(t *testing.T) {
+	svc := NewBasicMockService("Invalid", 0, 0)
+	if err := svc.ImportJSON(strings.NewReader("not json")); err == nil {
+		t.Error("Expected an error importing invalid JSON")
+	}
+}
+
+func TestMockServiceJSONRoundTripTTL(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(clockEpoch)
+	src := NewMockService("Source", WithClock(clock))
+	if err := src.PutDataWithTTL(ctx, "session", "token", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.PutData(ctx, "config", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := src.ExportJSON(&buf); err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+	dst := NewMockService("Destination", WithClock(clock))
+	if err := dst.ImportJSON(&buf); err != nil {
+		t.Fatalf("ImportJSON failed: %v", err)
+	}
+	if got, err := dst.GetData(ctx, "session"); err != nil || got != "token" {
+		t.Fatalf("Expected the imported key before its expiry, got %q, %v", got, err)
+	}
+
+	clock.Advance(time.Minute)
+	if _, err := dst.GetData(ctx, "session"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the imported key to expire, got %v", err)
+	}
+	if _, err := dst.GetData(ctx, "config"); err != nil {
+		t.Errorf("Expected the key without a TTL to remain, got %v", err)
+	}
+}
+
+func TestMockServiceImportJSONChecksWrites(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Limited", WithMaxValueSize(4))
+	data := `{"entries": {"a": "ok", "b": "too long"}}`
+	if err := svc.ImportJSON(strings.NewReader(data)); !errors.Is(err, ErrValueTooLarge) {
+		t.Fatalf("Expected ErrValueTooLarge, got %v", err)
+	}
+	if keys, err := svc.ListKeys(ctx); err != nil || len(keys) != 0 {
+		t.Errorf("Expected a rejected import to store nothing, got %v, %v", keys, err)
+	}
+}
+
+func TestMockServiceImportJSONChecksCapacity(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Small", WithMaxKeys(2, EvictReject))
+	if err := svc.PutData(ctx, "a", "old"); err != nil {
+		t.Fatal(err)
+	}
+	data := `{"entries": {"a": "new", "b": "2", "c": "3"}}`
+	if err := svc.ImportJSON(strings.NewReader(data)); !errors.Is(err, ErrCapacityFull) {
+		t.Fatalf("Expected ErrCapacityFull, got %v", err)
+	}
+	if keys, err := svc.ListKeys(ctx); err != nil || len(keys) != 1 {
+		t.Errorf("Expected a rejected import to store nothing, got %v, %v", keys, err)
+	}
+	if got, err := svc.GetData(ctx, "a"); err != nil || got != "old" {
+		t.Errorf("Expected a to keep its old value, got %q, %v", got, err)
+	}
+}
+
+func TestMockServiceExportJSONReadsStore(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("LRU", WithMaxKeys(2, EvictLRU))
+	for _, k := range []string{"a", "b"} {
+		if err := svc.PutData(ctx, k, "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := svc.CorruptKey("b"); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := svc.ExportJSON(&buf); err != nil {
+		t.Fatalf("Expected a corrupted key not to abort the export, got %v", err)
+	}
+	if !strings.Contains(buf.String(), `"a"`) || !strings.Contains(buf.String(), `"b"`) {
+		t.Errorf("Expected both keys exported, got %s", buf.String())
+	}
+
+	// Exporting is not a use, so a is still the least recently used key.
+	if err := svc.PutData(ctx, "c", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.GetData(ctx, "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected a to be evicted, got %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// LoadFixtures stores every file under dir in svc, using the file's path
+// relative to dir, with "/" separators, as the key and its contents as the
+// value. A file at dir/users/alice is stored under "users/alice".
+func LoadFixtures(svc *MockService, dir string) error {
+	ctx := context.Background()
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read fixture %s: %w", rel, err)
+		}
+		if err := svc.PutData(ctx, filepath.ToSlash(rel), string(data)); err != nil {
+			return fmt.Errorf("failed to load fixture %s: %w", rel, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadFixtures(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config":               "debug=true",
+		"users/alice":          `{"name":"alice"}`,
+		"users/bob":            `{"name":"bob"}`,
+		"images/2024/logo.svg": "<svg/>",
+	}
+	for name, contents := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := context.Background()
+	svc := NewMockService("Fixtures")
+	if err := LoadFixtures(svc, dir); err != nil {
+		t.Fatalf("LoadFixtures failed: %v", err)
+	}
+
+	for key, want := range files {
+		got, err := svc.GetData(ctx, key)
+		if err != nil {
+			t.Errorf("GetData(%q) failed: %v", key, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("GetData(%q) = %q, want %q", key, got, want)
+		}
+	}
+	keys, err := svc.ListKeys(ctx)
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+	if want := []string{"config", "images/2024/logo.svg", "users/alice", "users/bob"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Expected keys %v, got %v", want, keys)
+	}
+}
+
+func TestLoadFixturesErrors(t *testing.T) {
+	if err := LoadFixtures(NewMockService("Fixtures"), filepath.Join(t.TempDir(), "missing")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist for a missing directory, got %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "big"), []byte("too large"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadFixtures(NewMockService("Fixtures", WithMaxValueSize(2)), dir); !errors.Is(err, ErrValueTooLarge) {
+		t.Errorf("Expected ErrValueTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// NewHTTPHandler exposes svc as a small REST API:
+//
+//	POST   /connect     connect the service
+//	GET    /ping        health check
+//	GET    /keys        list keys as a JSON array
+//	GET    /keys/{key}  read a value
+//	POST   /keys/{key}  create a value, failing with 409 if it exists
+//	PUT    /keys/{key}  create or replace a value
+//	DELETE /keys/{key}  delete a value
+//
+// The key is the rest of the escaped path, unescaped, so keys may contain
+// "/", "." and ".." segments; requests under /keys/ bypass the path
+// cleaning of http.ServeMux, which would otherwise rewrite them.
+//
+// Service errors are mapped to status codes by statusFor, and named in an
+// X-Error-Code header so that clients can tell apart errors sharing a
+// status.
+func NewHTTPHandler(svc ExternalService) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/connect", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, http.MethodPost)
+			return
+		}
+		reply(w, svc.Connect(r.Context()), http.StatusNoContent)
+	})
+	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, http.MethodGet)
+			return
+		}
+		reply(w, svc.Ping(r.Context()), http.StatusNoContent)
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, http.MethodGet)
+			return
+		}
+		keys, err := svc.ListKeys(r.Context())
+		if err != nil {
+			reply(w, err, 0)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(append([]string{}, keys...))
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		escaped, ok := strings.CutPrefix(r.URL.EscapedPath(), "/keys/")
+		if !ok {
+			mux.ServeHTTP(w, r)
+			return
+		}
+		key, err := url.PathUnescape(escaped)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		serveKey(w, r, svc, key)
+	})
+}
+
+// serveKey handles a request for /keys/{key}.
+func serveKey(w http.ResponseWriter, r *http.Request, svc ExternalService, key string) {
+	ctx := r.Context()
+	switch r.Method {
+	case http.MethodGet:
+		value, err := svc.GetData(ctx, key)
+		if err != nil {
+			reply(w, err, 0)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		io.WriteString(w, value)
+	case http.MethodPost:
+		_, err := svc.GetData(ctx, key)
+		switch {
+		case err == nil:
+			http.Error(w, fmt.Sprintf("key %s already exists", key), http.StatusConflict)
+			return
+		case !errors.Is(err, ErrNotFound):
+			reply(w, err, 0)
+			return
+		}
+		fallthrough
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		status := http.StatusNoContent
+		if r.Method == http.MethodPost {
+			status = http.StatusCreated
+		}
+		reply(w, svc.PutData(ctx, key, string(body)), status)
+	case http.MethodDelete:
+		reply(w, svc.DeleteData(ctx, key), http.StatusNoContent)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete)
+	}
+}
+
+// reply writes status if err is nil, and otherwise the error with the
+// status statusFor maps it to.
+func reply(w http.ResponseWriter, err error, status int) {
+	if err != nil {
+		se := statusErrorFor(err)
+		if se.code != "" {
+			w.Header().Set(errorCodeHeader, se.code)
+		}
+		http.Error(w, err.Error(), se.status)
+		return
+	}
+	w.WriteHeader(status)
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+}
+
+// errorCodeHeader names the service error behind an error response.
+const errorCodeHeader = "X-Error-Code"
+
+// statusError is the HTTP status and error code a service error is sent
+// as.
+type statusError struct {
+	err    error
+	status int
+	code   string
+}
+
+// statusErrors maps service errors to HTTP status codes and error codes,
+// and back. The first matching entry wins, so more specific errors come
+// before the error classes.
+var statusErrors = []statusError{
+	{ErrNotFound, http.StatusNotFound, "NotFound"},
+	{ErrValueTooLarge, http.StatusRequestEntityTooLarge, "ValueTooLarge"},
+	{ErrInvalidKey, http.StatusBadRequest, "InvalidKey"},
+	{ErrTokenExpired, http.StatusUnauthorized, "TokenExpired"},
+	{ErrUnauthorized, http.StatusUnauthorized, "Unauthorized"},
+	{ErrNotConnected, http.StatusPreconditionFailed, "NotConnected"},
+	{ErrCorrupted, http.StatusUnprocessableEntity, "Corrupted"},
+	{ErrNoChecksum, http.StatusUnprocessableEntity, "NoChecksum"},
+	{ErrCapacityFull, http.StatusInsufficientStorage, "CapacityFull"},
+	{ErrRateLimited, http.StatusTooManyRequests, "RateLimited"},
+	{ErrIntegrity, http.StatusBadGateway, "Integrity"},
+	{ErrUnavailable, http.StatusServiceUnavailable, "Unavailable"},
+	{ErrNotReady, http.StatusServiceUnavailable, "NotReady"},
+	{ErrConnectionLost, http.StatusServiceUnavailable, "ConnectionLost"},
+	{ErrPoolExhausted, http.StatusServiceUnavailable, "PoolExhausted"},
+	{ErrBusy, http.StatusServiceUnavailable, "Busy"},
+	{ErrTransient, http.StatusServiceUnavailable, "Transient"},
+	{ErrPermanent, http.StatusInternalServerError, "Permanent"},
+}
+
+// statusFor returns the HTTP status code for a service error.
+func statusFor(err error) int {
+	return statusErrorFor(err).status
+}
+
+// statusErrorFor returns the statusErrors entry for err, or a bare 500 if
+// none matches.
+func statusErrorFor(err error) statusError {
+	for _, se := range statusErrors {
+		if errors.Is(err, se.err) {
+			return se
+		}
+	}
+	return statusError{status: http.StatusInternalServerError}
+}
+
+// errorFor returns the service error an error response stands for,
+// preferring its error code over its status. A status alone maps to the
+// most general error sent with it, such as ErrUnauthorized for 401.
+func errorFor(code string, status int) (error, bool) {
+	for _, se := range statusErrors {
+		if code != "" && se.code == code {
+			return se.err, true
+		}
+	}
+	for i := len(statusErrors) - 1; i >= 0; i-- {
+		if statusErrors[i].status == status {
+			return statusErrors[i].err, true
+		}
+	}
+	return nil, false
+}
+
+// HTTPMockService is an ExternalService that talks HTTP to a backend
+// service served by an in-process HTTP server on a loopback port, so that
+// tests exercise real HTTP round trips, status codes and bodies.
+type HTTPMockService struct {
+	backend ExternalService
+	server  *http.Server
+	url     string
+	client  *http.Client
+}
+
+// NewHTTPMockService starts an HTTP server serving backend through
+// NewHTTPHandler. Call Close to shut it down.
+func NewHTTPMockService(backend ExternalService) (*HTTPMockService, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start HTTP mock server: %w", err)
+	}
+	server := &http.Server{Handler: NewHTTPHandler(backend)}
+	go server.Serve(ln)
+	return &HTTPMockService{
+		backend: backend,
+		server:  server,
+		url:     "http://" + ln.Addr().String(),
+		client:  &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()},
+	}, nil
+}
+
+// URL returns the base URL of the server.
+func (s *HTTPMockService) URL() string {
+	return s.url
+}
+
+// Close shuts down the server and the client's idle connections.
+func (s *HTTPMockService) Close() error {
+	s.client.CloseIdleConnections()
+	return s.server.Close()
+}
+
+// Name returns the backend's name if it is a MetricsSource, and the
+// server's URL otherwise.
+func (s *HTTPMockService) Name() string {
+	if src, ok := s.backend.(MetricsSource); ok {
+		return src.Name()
+	}
+	return s.url
+}
+
+// Metrics returns the backend's metrics if it is a MetricsSource, and nil
+// otherwise.
+func (s *HTTPMockService) Metrics() map[string]OpMetrics {
+	if src, ok := s.backend.(MetricsSource); ok {
+		return src.Metrics()
+	}
+	return nil
+}
+
+func (s *HTTPMockService) Connect(ctx context.Context) error {
+	_, err := s.do(ctx, http.MethodPost, "/connect", "")
+	return err
+}
+
+func (s *HTTPMockService) Ping(ctx context.Context) error {
+	_, err := s.do(ctx, http.MethodGet, "/ping", "")
+	return err
+}
+
+func (s *HTTPMockService) GetData(ctx context.Context, key string) (string, error) {
+	return s.do(ctx, http.MethodGet, keyPath(key), "")
+}
+
+func (s *HTTPMockService) PutData(ctx context.Context, key string, value string) error {
+	_, err := s.do(ctx, http.MethodPut, keyPath(key), value)
+	return err
+}
+
+func (s *HTTPMockService) DeleteData(ctx context.Context, key string) error {
+	_, err := s.do(ctx, http.MethodDelete, keyPath(key), "")
+	return err
+}
+
+func (s *HTTPMockService) ListKeys(ctx context.Context) ([]string, error) {
+	body, err := s.do(ctx, http.MethodGet, "/keys", "")
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	if err := json.Unmarshal([]byte(body), &keys); err != nil {
+		return nil, fmt.Errorf("failed to decode key list: %w", err)
+	}
+	return keys, nil
+}
+
+func keyPath(key string) string {
+	return "/keys/" + url.PathEscape(key)
+}
+
+// do sends a request and returns the response body, turning error
+// statuses back into the service errors they stand for.
+func (s *HTTPMockService) do(ctx context.Context, method, path, body string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.url+path, strings.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+		return "", fmt.Errorf("%s %s: %v: %w", method, path, err, ErrUnavailable)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("%s %s: reading response: %v: %w", method, path, err, ErrTransient)
+	}
+	if resp.StatusCode < 300 {
+		return string(data), nil
+	}
+
+	msg := strings.TrimSpace(string(data))
+	if err, ok := errorFor(resp.Header.Get(errorCodeHeader), resp.StatusCode); ok {
+		return "", fmt.Errorf("%s %s: %s: %w", method, path, msg, err)
+	}
+	return "", fmt.Errorf("%s %s: unexpected status %d: %s: %w", method, path, resp.StatusCode, msg, ErrPermanent)
+}
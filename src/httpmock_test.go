@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newHTTPMock starts an HTTPMockService serving backend for the duration
+// of t.
+func newHTTPMock(t *testing.T, backend ExternalService) *HTTPMockService {
+	t.Helper()
+	srv, err := NewHTTPMockService(backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+func TestHTTPHandlerVerbs(t *testing.T) {
+	backend := NewMockService("API", WithDryRun(true), WithMaxValueSize(16))
+	srv := newHTTPMock(t, backend)
+
+	send := func(method, path, body string) (int, string) {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL()+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, strings.TrimSpace(string(data))
+	}
+
+	steps := []struct {
+		method, path, body string
+		wantStatus         int
+		wantBody           string // checked when not empty
+	}{
+		{http.MethodPost, "/connect", "", http.StatusNoContent, ""},
+		{http.MethodGet, "/ping", "", http.StatusNoContent, ""},
+		{http.MethodPost, "/keys/user", "alice", http.StatusCreated, ""},
+		{http.MethodPost, "/keys/user", "bob", http.StatusConflict, "key user already exists"},
+		{http.MethodGet, "/keys/user", "", http.StatusOK, "alice"},
+		{http.MethodPut, "/keys/user", "carol", http.StatusNoContent, ""},
+		{http.MethodGet, "/keys/user", "", http.StatusOK, "carol"},
+		{http.MethodPut, "/keys/big", strings.Repeat("x", 17), http.StatusRequestEntityTooLarge, "value too large"},
+		{http.MethodGet, "/keys", "", http.StatusOK, `["user"]`},
+		{http.MethodDelete, "/keys/user", "", http.StatusNoContent, ""},
+		{http.MethodDelete, "/keys/user", "", http.StatusNotFound, "not found"},
+		{http.MethodGet, "/keys/user", "", http.StatusNotFound, "not found"},
+		{http.MethodGet, "/keys", "", http.StatusOK, `[]`},
+		{http.MethodPatch, "/keys/user", "", http.StatusMethodNotAllowed, ""},
+		{http.MethodGet, "/connect", "", http.StatusMethodNotAllowed, ""},
+	}
+	for _, s := range steps {
+		status, body := send(s.method, s.path, s.body)
+		if status != s.wantStatus {
+			t.Errorf("%s %s: expected status %d, got %d (%s)", s.method, s.path, s.wantStatus, status, body)
+		}
+		if s.wantBody != "" && !strings.Contains(body, s.wantBody) {
+			t.Errorf("%s %s: expected body containing %q, got %q", s.method, s.path, s.wantBody, body)
+		}
+	}
+}
+
+func TestHTTPMockService(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMockService("API", WithDryRun(true))
+	srv := newHTTPMock(t, backend)
+
+	var svc ExternalService = srv
+	if err := svc.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := svc.Ping(ctx); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+
+	// Keys needing escaping survive the trip through the URL.
+	for _, key := range []string{"plain", "with space", "nested/path", "q?x=1"} {
+		if err := svc.PutData(ctx, key, "value of "+key); err != nil {
+			t.Fatalf("PutData(%q) failed: %v", key, err)
+		}
+		if got, err := svc.GetData(ctx, key); err != nil || got != "value of "+key {
+			t.Errorf("GetData(%q) = %q, %v", key, got, err)
+		}
+		if got, _ := backend.GetData(ctx, key); got != "value of "+key {
+			t.Errorf("Expected the backend to hold %q, got %q", key, got)
+		}
+	}
+
+	keys, err := svc.ListKeys(ctx)
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+	if want := []string{"nested/path", "plain", "q?x=1", "with space"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Expected keys %v, got %v", want, keys)
+	}
+
+	if err := svc.DeleteData(ctx, "plain"); err != nil {
+		t.Fatalf("DeleteData failed: %v", err)
+	}
+	if _, err := svc.GetData(ctx, "plain"); !errors.Is(err, ErrNotFound) || IsRetryable(err) {
+		t.Errorf("Expected a permanent ErrNotFound over HTTP, got %v", err)
+	}
+
+	flaky := newHTTPMock(t, NewMockService("Flaky API", WithFailureRate(1)))
+	if err := flaky.Ping(ctx); !IsRetryable(err) {
+		t.Errorf("Expected a backend failure to stay retryable over HTTP, got %v", err)
+	}
+}
+
+func TestHTTPMockServiceErrors(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMockService("API")
+	svc := newHTTPMock(t, backend)
+	if err := backend.PutData(ctx, "key", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.CorruptKey("key"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.GetData(ctx, "key"); !errors.Is(err, ErrCorrupted) {
+		t.Errorf("Expected ErrCorrupted over HTTP, got %v", err)
+	}
+
+	backend.Disconnect()
+	if err := svc.Ping(ctx); !errors.Is(err, ErrConnectionLost) || errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected ErrConnectionLost over HTTP, got %v", err)
+	}
+
+	strict := newHTTPMock(t, NewMockService("Strict", WithStrictConnect(true)))
+	if err := strict.Ping(ctx); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("Expected ErrNotConnected over HTTP, got %v", err)
+	}
+
+	flaky := newHTTPMock(t, NewMockService("Flaky", WithFailureRate(1)))
+	if err := flaky.Ping(ctx); !errors.Is(err, ErrTransient) || errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected a plain transient error over HTTP, got %v", err)
+	}
+
+	clock := NewFakeClock(clockEpoch)
+	auth := NewAuthService(NewMockService("Auth"), Credentials{ClientID: "demo"}, time.Minute)
+	auth.Clock = clock
+	if err := auth.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	if err := newHTTPMock(t, auth).Ping(ctx); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("Expected ErrTokenExpired over HTTP, got %v", err)
+	}
+}
+
+func TestHTTPMockServiceKeyPaths(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMockService("API")
+	svc := newHTTPMock(t, backend)
+
+	keys := []string{"a/b", "a//b", "a/../b", "./x", "../y", "..", ".", "a/", "/lead", "sp ace%20"}
+	for i, key := range keys {
+		if err := svc.PutData(ctx, key, fmt.Sprint(i)); err != nil {
+			t.Fatalf("PutData(%q) failed: %v", key, err)
+		}
+	}
+	for i, key := range keys {
+		if got, err := backend.GetData(ctx, key); err != nil || got != fmt.Sprint(i) {
+			t.Errorf("Expected key %q to hold %d, got %q, %v", key, i, got, err)
+		}
+		if got, err := svc.GetData(ctx, key); err != nil || got != fmt.Sprint(i) {
+			t.Errorf("Expected GetData(%q) over HTTP to return %d, got %q, %v", key, i, got, err)
+		}
+	}
+	if err := svc.DeleteData(ctx, "a/../b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := backend.GetData(ctx, "b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected no key b to be created, got %v", err)
+	}
+	if got, err := backend.GetData(ctx, "a/b"); err != nil || got != "0" {
+		t.Errorf("Expected a/b to be untouched, got %q, %v", got, err)
+	}
+}
+
+func TestHTTPMockServiceMetrics(t *testing.T) {
+	backend := NewMockService("API")
+	svc := newHTTPMock(t, backend)
+	if err := svc.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var src MetricsSource = svc
+	if src.Name() != "API" || src.Metrics()["Ping"].Count != 1 {
+		t.Errorf("Expected the backend's metrics, got %s %v", src.Name(), src.Metrics())
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+// recordingHandler is a slog.Handler that keeps every record it handles.
+type recordingHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r.Clone())
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *recordingHandler) WithGroup(string) slog.Handler      { return h }
+
+// attrs returns the attributes of record i by key.
+func (h *recordingHandler) attrs(i int) map[string]slog.Value {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make(map[string]slog.Value)
+	h.records[i].Attrs(func(a slog.Attr) bool {
+		out[a.Key] = a.Value
+		return true
+	})
+	return out
+}
+
+func TestOperationLogRecords(t *testing.T) {
+	ctx := context.Background()
+	h := &recordingHandler{}
+	svc := NewMockService("Logged", WithDryRun(true), WithLogger(slog.New(h)), WithMaxValueSize(4))
+
+	if err := svc.PutData(ctx, "key", "val"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	h.records = nil
+	if _, err := svc.GetData(ctx, "key"); err != nil {
+		t.Fatalf("GetData failed: %v", err)
+	}
+	if err := svc.PutData(ctx, "big", "too large"); !errors.Is(err, ErrValueTooLarge) {
+		t.Fatalf("Expected PutData to fail, got %v", err)
+	}
+
+	if len(h.records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(h.records))
+	}
+	for i, want := range []struct{ op, key string }{{"GetData", "key"}, {"PutData", "big"}} {
+		r := h.records[i]
+		if r.Level != slog.LevelDebug || r.Message != "operation completed" {
+			t.Errorf("Record %d: unexpected level %v and message %q", i, r.Level, r.Message)
+		}
+		attrs := h.attrs(i)
+		if attrs["service"].String() != "Logged" || attrs["op"].String() != want.op || attrs["key"].String() != want.key {
+			t.Errorf("Record %d: unexpected attributes %v", i, attrs)
+		}
+		if d, ok := attrs["duration"]; !ok || d.Kind() != slog.KindDuration {
+			t.Errorf("Record %d: expected a duration attribute, got %v", i, attrs)
+		}
+	}
+	if _, ok := h.attrs(0)["error"]; ok {
+		t.Error("Expected no error attribute for a successful GetData")
+	}
+	if err, ok := h.attrs(1)["error"].Any().(error); !ok || !errors.Is(err, ErrValueTooLarge) {
+		t.Errorf("Expected the failed PutData's error attribute, got %v", h.attrs(1)["error"])
+	}
+}
+
+func TestConnectLogRecord(t *testing.T) {
+	h := &recordingHandler{}
+	svc := NewMockService("Logged", WithDryRun(true), WithLogger(slog.New(h)))
+
+	ctx := ContextWithTraceID(context.Background(), "trace-1")
+	if err := svc.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if len(h.records) == 0 || h.records[0].Level != slog.LevelInfo || h.records[0].Message != "connected" {
+		t.Fatalf("Expected an info record for the connection, got %v", h.records)
+	}
+	if attrs := h.attrs(0); attrs["service"].String() != "Logged" || attrs["trace_id"].String() != "trace-1" {
+		t.Errorf("Unexpected attributes %v", attrs)
+	}
+}
+
+func TestDefaultLogger(t *testing.T) {
+	h := &recordingHandler{}
+	old := slog.Default()
+	slog.SetDefault(slog.New(h))
+	t.Cleanup(func() { slog.SetDefault(old) })
+
+	svc := NewMockService("Unconfigured", WithDryRun(true))
+	if svc.logger() != slog.Default() {
+		t.Error("Expected a service without a Logger to use slog.Default()")
+	}
+	if err := svc.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if len(h.records) != 1 {
+		t.Errorf("Expected the default logger to receive the Ping record, got %d", len(h.records))
+	}
+}
@@ -1,11 +1,26 @@
 package main
 
 import (
+	"container/list"
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"math/rand"
+	"net/http"
 	"os"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 )
 
@@ -15,189 +30,1514 @@ type ExternalService interface {
 	Ping(ctx context.Context) error
 	GetData(ctx context.Context, key string) (string, error)
 	PutData(ctx context.Context, key string, value string) error
+	DeleteData(ctx context.Context, key string) error
 	ListKeys(ctx context.Context) ([]string, error)
 }
 
 // MockService simulates an external service
 type MockService struct {
+	// Namespace, when set, is prepended as "namespace:" to every stored key
+	// so services sharing a Store do not collide.
+	Namespace string
+
+	// DryRun skips all simulated latency and failures so the full flow can
+	// be validated quickly.
+	DryRun bool
+
+	// OnOperation, when set, is called after every operation completes
+	// with the operation name, key (empty for keyless operations), elapsed
+	// time and resulting error.
+	OnOperation func(op string, key string, dur time.Duration, err error)
+
+	// Logger receives a debug record per completed operation, with the
+	// service, operation, key, duration, error and any trace or request ID
+	// carried by the operation's context as attributes. When nil,
+	// slog.Default() is used.
+	Logger *slog.Logger
+
+	// Tracer, when set, receives a span for every operation.
+	Tracer Tracer
+
+	// PoolSize limits how many operations may be in flight at once,
+	// modelling a finite connection pool. Callers beyond the limit block
+	// until a connection frees up or their context is done. Zero means
+	// unlimited.
+	PoolSize int
+
+	// PoolTimeout bounds how long a caller waits for a free connection
+	// when the pool is full; it then fails with ErrPoolExhausted. Zero
+	// means waiting until the context is done.
+	PoolTimeout time.Duration
+
+	// RejectWhenBusy makes callers beyond PoolSize fail at once with
+	// ErrBusy instead of waiting for a free connection.
+	RejectWhenBusy bool
+
+	// WarmupCalls is the number of operations after each Connect that run
+	// slower than normal. The first one takes responseTime*WarmupFactor and
+	// the penalty decays linearly back to responseTime.
+	WarmupCalls  int
+	WarmupFactor float64
+
+	// ColdStartDelay is extra latency added to the service's first Connect.
+	ColdStartDelay time.Duration
+
+	// StrictConnect makes every operation but Connect fail with
+	// ErrNotConnected until Connect succeeds, and again after Close. By
+	// default operations work without connecting first.
+	StrictConnect bool
+
+	// ReadinessDelay makes Connect fail with ErrNotReady until this long
+	// after the service was created, like a database still starting up.
+	ReadinessDelay time.Duration
+
+	// MaxValueSize is the largest value, in bytes, PutData accepts. Larger
+	// values are rejected with ErrValueTooLarge. Zero means unlimited.
+	MaxValueSize int
+
+	// MaxKeyLength is the longest key, in bytes, PutData accepts, and
+	// KeyPattern, when set, must match every key PutData accepts. Keys
+	// breaking either rule are rejected with ErrInvalidKey. Zero and nil
+	// mean no restriction.
+	MaxKeyLength int
+	KeyPattern   *regexp.Regexp
+
+	// BytesPerSecond models limited bandwidth: reads and writes take an
+	// extra len(value)/BytesPerSecond seconds on top of the response time,
+	// so large transfers are slow. Zero means unlimited bandwidth.
+	BytesPerSecond int
+
+	// CorruptionRate is the fraction of GetData calls, in [0, 1], that
+	// return the stored value with one bit flipped, simulating bit rot.
+	// The stored value itself is left intact.
+	CorruptionRate float32
+
+	// ReplicationLag models an eventually consistent store: a written key
+	// reads as not found until ReplicationLag has passed since the write.
+	// Zero means writes are visible immediately.
+	ReplicationLag time.Duration
+
+	// MaxKeys bounds how many keys the service holds, like a bounded
+	// cache. When a write of a new key would exceed it, Eviction decides
+	// whether the write fails with ErrCapacityFull or an older key is
+	// evicted to make room. Zero means unbounded.
+	MaxKeys  int
+	Eviction EvictionPolicy
+
+	// BlockWhilePaused makes operations on a paused service wait for Resume
+	// instead of failing with ErrUnavailable.
+	BlockWhilePaused bool
+
+	// RateLimit caps the number of operations accepted per second. Excess
+	// operations fail with ErrRateLimited. Zero means unlimited.
+	RateLimit int
+
+	// TruncateAbove makes ListKeysMaybeTruncated degrade under load: while
+	// more than this many operations are in flight, counting the listing
+	// itself, it returns only the first half of the keys, flagged as
+	// truncated, instead of failing. Zero disables truncation.
+	TruncateAbove int
+
+	// ResponseTemplate makes reads treat stored values as text/template
+	// templates, like a mock API generating dynamic responses. The
+	// functions {{key}} and {{timestamp}} expand to the key read and the
+	// current time in RFC 3339 format. The stored value is left as is.
+	ResponseTemplate bool
+
+	// ErrorFormatter, when set, rewrites the error of every failed
+	// operation, letting integrators mimic the error text of a specific
+	// SDK or add context such as a region. Nil keeps the default messages.
+	ErrorFormatter ErrorFormatter
+
 	name         string
 	responseTime time.Duration
 	failureRate  float32
-	data         map[string]string
+	retries      int
+	rng          *rand.Rand
+	clock        Clock
+	created      time.Time
+	// connectTime, when non-nil, replaces responseTime as the latency of
+	// Connect.
+	connectTime *time.Duration
+
+	mu          sync.Mutex
+	store       Store
+	warmupCount int
+	// connectedBefore is set once the first Connect has been attempted.
+	connectedBefore bool
+	rateWindow      time.Time
+	rateCount       int
+	metrics         map[string]OpMetrics
+	// connected is set by a successful Connect and cleared by Close.
+	connected bool
+	// disconnected is set by Disconnect and cleared by a successful
+	// Connect.
+	disconnected bool
+	// meta holds the metadata of each stored key, by storage key.
+	meta map[string]Metadata
+	// visibleAt holds when each lagging write becomes readable, by storage
+	// key.
+	visibleAt map[string]time.Time
+	// expiresAt holds when each key written with a TTL expires, by storage
+	// key.
+	expiresAt map[string]time.Time
+	// checksums holds the checksum of each value written with
+	// PutDataChecksummed, by storage key.
+	checksums map[string]string
+	// corrupted holds the storage keys damaged by CorruptKey, whose values
+	// load verifies against their CRC32.
+	corrupted map[string]bool
+	// usageOrder lists the storage keys written through this service from
+	// first to evict to last, and usage indexes it by key. They back
+	// MaxKeys.
+	usageOrder list.List
+	usage      map[string]*list.Element
+	// liveKeys counts the keys written through this service that have not
+	// since been deleted, evicted or expired. VerifyConsistency checks it
+	// against the store.
+	liveKeys int
+
+	poolOnce sync.Once
+	pool     chan struct{}
+	// inFlight counts operations between begin and their release.
+	inFlight atomic.Int32
+
+	recorder *recorder
+
+	// resumed is non-nil while the service is paused and is closed by
+	// Resume. It is guarded by mu.
+	resumed chan struct{}
+
+	hooksMu     sync.Mutex
+	beforeHooks []func(op string, key string)
+	afterHooks  []func(op string, key string, err error, dur time.Duration)
 }
 
-// NewMockService creates a new mock service
-func NewMockService(name string, responseTime time.Duration, failureRate float32) *MockService {
-	return &MockService{
-		name:         name,
-		responseTime: responseTime,
-		failureRate:  failureRate,
-		data:         make(map[string]string),
+// NewMockService creates a new mock service configured by opts. Without
+// options the service responds instantly and never fails.
+func NewMockService(name string, opts ...Option) *MockService {
+	m := &MockService{
+		name:  name,
+		store: NewMemoryStore(),
+		clock: RealClock{},
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.created = m.now()
+	return m
+}
+
+// NewBasicMockService creates a mock service with the given latency and
+// failure rate. It is equivalent to calling NewMockService with
+// WithResponseTime and WithFailureRate.
+func NewBasicMockService(name string, responseTime time.Duration, failureRate float32) *MockService {
+	return NewMockService(name, WithResponseTime(responseTime), WithFailureRate(failureRate))
 }
 
 // Connect simulates connecting to the service
-func (m *MockService) Connect(ctx context.Context) error {
-	time.Sleep(m.responseTime)
+func (m *MockService) Connect(ctx context.Context) (err error) {
+	defer m.observe(ctx, m.startOp("Connect", ""), &err)
+	release, err := m.admit(ctx, m.connectLatency())
+	if err != nil {
+		return err
+	}
+	defer release()
+	if ready := m.created.Add(m.ReadinessDelay); !m.DryRun && m.now().Before(ready) {
+		return fmt.Errorf("%s is starting up, ready in %v: %w", m.name, ready.Sub(m.now()), ErrNotReady)
+	}
 	if m.shouldFail() {
-		return fmt.Errorf("failed to connect to %s", m.name)
+		return fmt.Errorf("failed to connect to %s: %w", m.name, ErrTransient)
 	}
-	fmt.Printf("✓ Connected to %s\n", m.name)
+	m.mu.Lock()
+	m.warmupCount = 0
+	m.connected = true
+	m.disconnected = false
+	m.mu.Unlock()
+	attrs := append([]slog.Attr{slog.String("service", m.name)}, contextAttrs(ctx)...)
+	m.logger().LogAttrs(ctx, slog.LevelInfo, "connected", attrs...)
 	return nil
 }
 
 // Ping simulates a health check
-func (m *MockService) Ping(ctx context.Context) error {
-	time.Sleep(m.responseTime / 2)
+func (m *MockService) Ping(ctx context.Context) (err error) {
+	defer m.observe(ctx, m.startOp("Ping", ""), &err)
+	release, err := m.begin(ctx, m.warmupLatency(m.responseTime/2))
+	if err != nil {
+		return err
+	}
+	defer release()
 	if m.shouldFail() {
-		return fmt.Errorf("%s is not responding", m.name)
+		return fmt.Errorf("%s is not responding: %w", m.name, ErrTransient)
 	}
 	return nil
 }
 
 // GetData retrieves data from the mock service
 func (m *MockService) GetData(ctx context.Context, key string) (string, error) {
-	time.Sleep(m.responseTime)
+	return m.read(ctx, "GetData", key, nil)
+}
+
+// GetDataBytes retrieves the raw bytes stored under key. Values are kept
+// byte for byte, so binary data round-trips unchanged whichever of the
+// string or byte methods wrote it.
+func (m *MockService) GetDataBytes(ctx context.Context, key string) ([]byte, error) {
+	val, err := m.read(ctx, "GetDataBytes", key, nil)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(val), nil
+}
+
+// read runs the op operation, which reads key, possibly corrupting the
+// value as CorruptionRate dictates. If the read succeeds, check is called
+// with the key's storage key and the value returned, while m.mu is still
+// held, and any error it returns fails the read.
+func (m *MockService) read(ctx context.Context, op, key string, check func(k, val string) error) (_ string, err error) {
+	defer m.observe(ctx, m.startOp(op, key), &err)
+	release, err := m.begin(ctx, m.warmupLatency(m.responseTime))
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	if m.shouldFail() {
+		return "", fmt.Errorf("failed to get data from %s: %w", m.name, ErrTransient)
+	}
+	corrupt := m.shouldCorrupt()
+	val, err := m.readLocked(key, corrupt, check)
+	if err != nil {
+		return "", err
+	}
+	if m.ResponseTemplate {
+		if val, err = m.render(key, val); err != nil {
+			return "", err
+		}
+	}
+	// Model downloading the value once the service has found it.
+	if err := m.sleep(ctx, m.transferTime(len(val))); err != nil {
+		return "", err
+	}
+	return val, nil
+}
+
+// readLocked loads key under m.mu for read.
+func (m *MockService) readLocked(key string, corrupt bool, check func(k, val string) error) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	val, err := m.load(key)
+	if err != nil {
+		return "", err
+	}
+	if corrupt {
+		val = m.corrupt(val)
+	}
+	if check != nil {
+		if err := check(m.storageKey(key), val); err != nil {
+			return "", err
+		}
+	}
+	return val, nil
+}
+
+// transferTime returns how long moving n bytes takes at BytesPerSecond.
+func (m *MockService) transferTime(n int) time.Duration {
+	if m.BytesPerSecond <= 0 {
+		return 0
+	}
+	return time.Duration(float64(n) / float64(m.BytesPerSecond) * float64(time.Second))
+}
+
+// Exists reports whether key is present without transferring its value,
+// like a HEAD request against an object store.
+func (m *MockService) Exists(ctx context.Context, key string) (_ bool, err error) {
+	defer m.observe(ctx, m.startOp("Exists", key), &err)
+	release, err := m.begin(ctx, m.warmupLatency(m.responseTime))
+	if err != nil {
+		return false, err
+	}
+	defer release()
 	if m.shouldFail() {
-		return "", fmt.Errorf("failed to get data from %s", m.name)
+		return false, fmt.Errorf("failed to check key in %s: %w", m.name, ErrTransient)
 	}
-	if val, ok := m.data[key]; ok {
-		return val, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err = m.load(key)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// GetDataOrDefault retrieves data like GetData but returns def when the key
+// does not exist. Simulated failures are still returned as errors.
+func (m *MockService) GetDataOrDefault(ctx context.Context, key, def string) (string, error) {
+	val, err := m.GetData(ctx, key)
+	if errors.Is(err, ErrNotFound) {
+		return def, nil
 	}
-	return "", fmt.Errorf("key %s not found", key)
+	return val, err
 }
 
 // PutData stores data in the mock service
 func (m *MockService) PutData(ctx context.Context, key string, value string) error {
-	time.Sleep(m.responseTime)
+	return m.write(ctx, "PutData", key, value, nil)
+}
+
+// PutDataBytes stores a copy of value under key. value need not be valid
+// UTF-8.
+func (m *MockService) PutDataBytes(ctx context.Context, key string, value []byte) error {
+	return m.write(ctx, "PutDataBytes", key, string(value), nil)
+}
+
+// write runs the op operation, which stores value under key. If the write
+// succeeds, after is called with the key's storage key while m.mu is still
+// held, letting variants of PutData attach extra state to the key.
+func (m *MockService) write(ctx context.Context, op, key, value string, after func(k string)) (err error) {
+	defer m.observe(ctx, m.startOp(op, key).withValue(value), &err)
+	return m.put(ctx, key, value, after)
+}
+
+// put performs the body of write for an operation the caller observes.
+func (m *MockService) put(ctx context.Context, key, value string, after func(k string)) error {
+	release, err := m.begin(ctx, m.warmupLatency(m.responseTime)+m.transferTime(len(value)))
+	if err != nil {
+		return err
+	}
+	defer release()
+	if err := m.checkWrite(key, value); err != nil {
+		return err
+	}
 	if m.shouldFail() {
-		return fmt.Errorf("failed to put data to %s", m.name)
+		return fmt.Errorf("failed to put data to %s: %w", m.name, ErrTransient)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.save(key, value); err != nil {
+		return err
+	}
+	if after != nil {
+		after(m.storageKey(key))
+	}
+	return nil
+}
+
+// checkWrite checks that key and value are acceptable to write.
+func (m *MockService) checkWrite(key, value string) error {
+	if err := m.validateKey(key); err != nil {
+		return err
+	}
+	if m.MaxValueSize > 0 && len(value) > m.MaxValueSize {
+		return fmt.Errorf("%d bytes for key %s exceeds %d byte limit: %w", len(value), key, m.MaxValueSize, ErrValueTooLarge)
+	}
+	return nil
+}
+
+// validateKey checks key against MaxKeyLength and KeyPattern.
+func (m *MockService) validateKey(key string) error {
+	if m.MaxKeyLength > 0 && len(key) > m.MaxKeyLength {
+		return fmt.Errorf("key %q is %d bytes, exceeding the %d byte limit: %w", key, len(key), m.MaxKeyLength, ErrInvalidKey)
+	}
+	if m.KeyPattern != nil && !m.KeyPattern.MatchString(key) {
+		return fmt.Errorf("key %q does not match %s: %w", key, m.KeyPattern, ErrInvalidKey)
 	}
-	m.data[key] = value
 	return nil
 }
 
-// ListKeys returns all keys in the mock service
-func (m *MockService) ListKeys(ctx context.Context) ([]string, error) {
-	time.Sleep(m.responseTime)
+// DeleteData removes a key from the mock service
+func (m *MockService) DeleteData(ctx context.Context, key string) (err error) {
+	defer m.observe(ctx, m.startOp("DeleteData", key), &err)
+	release, err := m.begin(ctx, m.warmupLatency(m.responseTime))
+	if err != nil {
+		return err
+	}
+	defer release()
 	if m.shouldFail() {
-		return nil, fmt.Errorf("failed to list keys from %s", m.name)
+		return fmt.Errorf("failed to delete data from %s: %w", m.name, ErrTransient)
 	}
-	keys := make([]string, 0, len(m.data))
-	for k := range m.data {
-		keys = append(keys, k)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.remove(key)
+}
+
+// DeletePrefix removes every key that starts with prefix and returns how
+// many were removed. The whole deletion costs a single round trip. An
+// empty prefix is rejected with ErrPermanent rather than wiping the
+// service.
+func (m *MockService) DeletePrefix(ctx context.Context, prefix string) (_ int, err error) {
+	defer m.observe(ctx, m.startOp("DeletePrefix", prefix), &err)
+	if prefix == "" {
+		return 0, fmt.Errorf("refusing to delete every key from %s: empty prefix: %w", m.name, ErrPermanent)
+	}
+	release, err := m.begin(ctx, m.warmupLatency(m.responseTime))
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	if m.shouldFail() {
+		return 0, fmt.Errorf("failed to delete data from %s: %w", m.name, ErrTransient)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys, err := m.keys()
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if err := m.remove(key); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// GetMulti retrieves several keys in a single round trip. Missing keys are
+// omitted from the result. A simulated failure fails the whole call; no
+// partial result is returned.
+func (m *MockService) GetMulti(ctx context.Context, keys []string) (_ map[string]string, err error) {
+	defer m.observe(ctx, m.startOp("GetMulti", ""), &err)
+	release, err := m.begin(ctx, m.warmupLatency(m.responseTime))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if m.shouldFail() {
+		return nil, fmt.Errorf("failed to get data from %s: %w", m.name, ErrTransient)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	values := make(map[string]string, len(keys))
+	for _, key := range keys {
+		val, err := m.load(key)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		values[key] = val
+	}
+	return values, nil
+}
+
+// ListKeys returns all keys in the mock service in lexicographic order,
+// whatever order its Store lists them in, so that results can be compared
+// directly.
+func (m *MockService) ListKeys(ctx context.Context) (_ []string, err error) {
+	defer m.observe(ctx, m.startOp("ListKeys", ""), &err)
+	release, err := m.begin(ctx, m.warmupLatency(m.responseTime))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if m.shouldFail() {
+		return nil, fmt.Errorf("failed to list keys from %s: %w", m.name, ErrTransient)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.keys()
+}
+
+// operation tracks an in-flight operation from startOp to observe.
+type operation struct {
+	name   string
+	key    string
+	value  string
+	writes []WriteRecord
+	start  time.Time
+	span   Span
+}
+
+// startOp records the start of an operation and opens its trace span.
+func (m *MockService) startOp(name, key string) *operation {
+	tracer := m.Tracer
+	if tracer == nil {
+		tracer = noopTracer{}
+	}
+	m.hooksMu.Lock()
+	hooks := m.beforeHooks
+	m.hooksMu.Unlock()
+	for _, hook := range hooks {
+		hook(name, key)
+	}
+	return &operation{name: name, key: key, start: m.now(), span: tracer.StartSpan(name)}
+}
+
+// withValue attaches the value written by the operation, which is captured
+// when recording is enabled.
+func (op *operation) withValue(value string) *operation {
+	op.value = value
+	return op
+}
+
+// observe ends op's span and reports the completed operation to the
+// recorder, the Logger and the OnOperation hook. It is deferred before any
+// lock is taken so the hook never runs under m.mu. Errors are prefixed with
+// the request ID carried by ctx, if any, after ErrorFormatter has run.
+func (m *MockService) observe(ctx context.Context, op *operation, err *error) {
+	op.span.End()
+	if *err != nil {
+		if m.ErrorFormatter != nil {
+			*err = m.ErrorFormatter(op.name, m.name, *err)
+		}
+		if id, ok := RequestIDFromContext(ctx); ok {
+			*err = fmt.Errorf("[%s] %w", id, *err)
+		}
+	}
+	dur := m.now().Sub(op.start)
+	m.record(ctx, op, dur, *err)
+	m.countOp(op.name, dur, *err)
+	if logger := m.logger(); logger.Enabled(ctx, slog.LevelDebug) {
+		attrs := []slog.Attr{slog.String("service", m.name), slog.String("op", op.name)}
+		if op.key != "" {
+			attrs = append(attrs, slog.String("key", op.key))
+		}
+		attrs = append(attrs, slog.Duration("duration", dur))
+		attrs = append(attrs, contextAttrs(ctx)...)
+		if *err != nil {
+			attrs = append(attrs, slog.Any("error", *err))
+		}
+		logger.LogAttrs(ctx, slog.LevelDebug, "operation completed", attrs...)
+	}
+	if m.OnOperation != nil {
+		m.OnOperation(op.name, op.key, dur, *err)
+	}
+	m.hooksMu.Lock()
+	hooks := m.afterHooks
+	m.hooksMu.Unlock()
+	for _, hook := range hooks {
+		hook(op.name, op.key, *err, dur)
 	}
-	return keys, nil
+}
+
+// OnBefore registers fn to be called as each operation starts, with the
+// operation name and key (empty for keyless operations). Hooks run in the
+// order they were registered.
+func (m *MockService) OnBefore(fn func(op string, key string)) {
+	m.hooksMu.Lock()
+	defer m.hooksMu.Unlock()
+	m.beforeHooks = append(m.beforeHooks[:len(m.beforeHooks):len(m.beforeHooks)], fn)
+}
+
+// OnAfter registers fn to be called after each operation completes,
+// whether or not it failed, with the operation name, key, resulting error
+// and elapsed time. Hooks run in the order they were registered.
+func (m *MockService) OnAfter(fn func(op string, key string, err error, dur time.Duration)) {
+	m.hooksMu.Lock()
+	defer m.hooksMu.Unlock()
+	m.afterHooks = append(m.afterHooks[:len(m.afterHooks):len(m.afterHooks)], fn)
 }
 
 func (m *MockService) shouldFail() bool {
-	return rand.Float32() < m.failureRate
+	if m.DryRun {
+		return false
+	}
+	for attempt := 0; attempt <= m.retries; attempt++ {
+		if m.roll() >= m.failureRate {
+			return false
+		}
+	}
+	return true
+}
+
+// shouldCorrupt decides whether a read returns a corrupted value.
+func (m *MockService) shouldCorrupt() bool {
+	return !m.DryRun && m.CorruptionRate > 0 && m.roll() < m.CorruptionRate
+}
+
+// corrupt returns val with one bit flipped at a random position, or a
+// single zero byte if val is empty. Callers must hold m.mu.
+func (m *MockService) corrupt(val string) string {
+	if val == "" {
+		return "\x00"
+	}
+	var i int
+	if m.rng == nil {
+		i = rand.Intn(len(val))
+	} else {
+		i = m.rng.Intn(len(val))
+	}
+	b := []byte(val)
+	b[i] ^= 1
+	return string(b)
+}
+
+// roll returns a random number in [0, 1) from the service's seeded source
+// if it has one, and from the global source otherwise.
+func (m *MockService) roll() float32 {
+	if m.rng == nil {
+		return rand.Float32()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rng.Float32()
+}
+
+// begin starts an operation: it rejects the call if the connection was
+// dropped, or if StrictConnect is set and the service is not connected,
+// then admits it. The returned func must
+// be called once the operation completes.
+func (m *MockService) begin(ctx context.Context, latency time.Duration) (func(), error) {
+	if err := m.checkConnected(); err != nil {
+		return nil, err
+	}
+	return m.admit(ctx, latency)
+}
+
+// checkConnected returns ErrConnectionLost after Disconnect, and
+// ErrNotConnected if StrictConnect is set and the service is not
+// connected.
+func (m *MockService) checkConnected() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.disconnected {
+		return fmt.Errorf("%s dropped the connection: %w", m.name, ErrConnectionLost)
+	}
+	if m.StrictConnect && !m.connected {
+		return fmt.Errorf("%s: call Connect first: %w", m.name, ErrNotConnected)
+	}
+	return nil
+}
+
+// Close disconnects the service. With StrictConnect set, operations fail
+// with ErrNotConnected until the next successful Connect. Close always
+// returns nil.
+func (m *MockService) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.connected = false
+	return nil
+}
+
+// Disconnect simulates the service dropping its connection: every
+// operation but Connect fails with ErrConnectionLost until the next
+// successful Connect.
+func (m *MockService) Disconnect() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.connected = false
+	m.disconnected = true
+}
+
+// admit starts an operation: it rejects or holds the call while the
+// service is paused, takes a connection from the pool, applies the rate
+// limit and simulates latency. The returned func must be called once the
+// operation completes.
+func (m *MockService) admit(ctx context.Context, latency time.Duration) (_ func(), err error) {
+	m.inFlight.Add(1)
+	defer func() {
+		if err != nil {
+			m.inFlight.Add(-1)
+		}
+	}()
+	if err := m.waitIfPaused(ctx); err != nil {
+		return nil, err
+	}
+	release, err := m.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.checkRateLimit(); err != nil {
+		release()
+		return nil, err
+	}
+	if err := m.sleep(ctx, latency); err != nil {
+		release()
+		return nil, err
+	}
+	return func() {
+		release()
+		m.inFlight.Add(-1)
+	}, nil
+}
+
+// checkRateLimit enforces RateLimit using a fixed one-second window.
+func (m *MockService) checkRateLimit() error {
+	if m.RateLimit <= 0 {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	if now.Sub(m.rateWindow) >= time.Second {
+		m.rateWindow = now
+		m.rateCount = 0
+	}
+	if m.rateCount >= m.RateLimit {
+		return fmt.Errorf("%s: %w", m.name, ErrRateLimited)
+	}
+	m.rateCount++
+	return nil
+}
+
+// acquire takes a connection from the pool, blocking until one is free,
+// PoolTimeout passes or ctx is done, or failing at once if RejectWhenBusy
+// is set. The returned func gives the connection back.
+func (m *MockService) acquire(ctx context.Context) (func(), error) {
+	if m.PoolSize <= 0 {
+		return func() {}, nil
+	}
+	m.poolOnce.Do(func() {
+		m.pool = make(chan struct{}, m.PoolSize)
+	})
+	select {
+	case m.pool <- struct{}{}:
+		return func() { <-m.pool }, nil
+	default:
+	}
+	if m.RejectWhenBusy {
+		return nil, fmt.Errorf("%s is handling %d operations: %w", m.name, m.PoolSize, ErrBusy)
+	}
+	var timeout <-chan time.Time
+	if m.PoolTimeout > 0 {
+		timeout = m.clock.After(m.PoolTimeout)
+	}
+	select {
+	case m.pool <- struct{}{}:
+		return func() { <-m.pool }, nil
+	case <-timeout:
+		return nil, fmt.Errorf("no free connection to %s after %v: %w", m.name, m.PoolTimeout, ErrPoolExhausted)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// connectLatency returns the latency of a Connect call, adding
+// ColdStartDelay to the first one.
+func (m *MockService) connectLatency() time.Duration {
+	latency := m.responseTime
+	if m.connectTime != nil {
+		latency = *m.connectTime
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connectedBefore {
+		return latency
+	}
+	m.connectedBefore = true
+	return latency + m.ColdStartDelay
+}
+
+// warmupLatency scales d for operations that fall within the warmup window
+// following Connect.
+func (m *MockService) warmupLatency(d time.Duration) time.Duration {
+	if m.WarmupCalls <= 0 || m.WarmupFactor <= 1 {
+		return d
+	}
+	m.mu.Lock()
+	n := m.warmupCount
+	if n < m.WarmupCalls {
+		m.warmupCount++
+	}
+	m.mu.Unlock()
+	if n >= m.WarmupCalls {
+		return d
+	}
+	factor := m.WarmupFactor - (m.WarmupFactor-1)*float64(n)/float64(m.WarmupCalls)
+	return time.Duration(float64(d) * factor)
+}
+
+// sleep simulates latency unless the service is in dry-run mode. It
+// returns early with the context's error if ctx is done first.
+func (m *MockService) sleep(ctx context.Context, d time.Duration) error {
+	if m.DryRun || d <= 0 {
+		return ctx.Err()
+	}
+	select {
+	case <-m.clock.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// logger returns the service's Logger, or slog.Default() if none is set.
+func (m *MockService) logger() *slog.Logger {
+	if m.Logger != nil {
+		return m.Logger
+	}
+	return slog.Default()
+}
+
+// now returns the current time according to the service's clock.
+func (m *MockService) now() time.Time {
+	return m.clock.Now()
+}
+
+// load reads key from the store, failing with ErrCorrupted if CorruptKey
+// damaged its value. Callers must hold m.mu.
+func (m *MockService) load(key string) (string, error) {
+	if !m.visible(m.storageKey(key)) || m.expired(m.storageKey(key)) {
+		return "", fmt.Errorf("key %s %w", key, ErrNotFound)
+	}
+	val, err := m.store.Get(m.storageKey(key))
+	if errors.Is(err, ErrNotFound) {
+		return "", fmt.Errorf("key %s %w", key, ErrNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s from %s: %w", key, m.name, err)
+	}
+	if err := m.verify(key, val); err != nil {
+		return "", err
+	}
+	m.used(m.storageKey(key))
+	return val, nil
+}
+
+// save writes key to the store. Callers must hold m.mu.
+func (m *MockService) save(key, value string) error {
+	if err := m.makeRoom(m.storageKey(key)); err != nil {
+		return fmt.Errorf("failed to write %s to %s: %w", key, m.name, err)
+	}
+	if err := m.store.Set(m.storageKey(key), value); err != nil {
+		return fmt.Errorf("failed to write %s to %s: %w", key, m.name, err)
+	}
+	m.track(m.storageKey(key))
+	if _, ok := m.meta[m.storageKey(key)]; !ok {
+		m.liveKeys++
+	}
+	m.touch(m.storageKey(key), value)
+	delete(m.expiresAt, m.storageKey(key))
+	delete(m.checksums, m.storageKey(key))
+	delete(m.corrupted, m.storageKey(key))
+	if m.ReplicationLag > 0 {
+		if m.visibleAt == nil {
+			m.visibleAt = make(map[string]time.Time)
+		}
+		m.visibleAt[m.storageKey(key)] = m.now().Add(m.ReplicationLag)
+	}
+	return nil
+}
+
+// visible reports whether storage key k has finished replicating. Callers
+// must hold m.mu.
+func (m *MockService) visible(k string) bool {
+	at, ok := m.visibleAt[k]
+	if !ok {
+		return true
+	}
+	if m.now().Before(at) {
+		return false
+	}
+	delete(m.visibleAt, k)
+	return true
+}
+
+// remove deletes key from the store. Callers must hold m.mu.
+func (m *MockService) remove(key string) error {
+	err := m.store.Delete(m.storageKey(key))
+	if errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("key %s %w", key, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete %s from %s: %w", key, m.name, err)
+	}
+	m.forget(m.storageKey(key))
+	return nil
+}
+
+// forget drops everything tracked about storage key k after it left the
+// store. Callers must hold m.mu.
+func (m *MockService) forget(k string) {
+	if _, ok := m.meta[k]; ok {
+		m.liveKeys--
+	}
+	delete(m.meta, k)
+	delete(m.visibleAt, k)
+	delete(m.expiresAt, k)
+	delete(m.checksums, k)
+	delete(m.corrupted, k)
+	if e, ok := m.usage[k]; ok {
+		m.usageOrder.Remove(e)
+		delete(m.usage, k)
+	}
+}
+
+// keys lists the caller-visible keys in the store in sorted order. Callers
+// must hold m.mu.
+func (m *MockService) keys() ([]string, error) {
+	stored, err := m.store.Keys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys from %s: %w", m.name, err)
+	}
+	keys := make([]string, 0, len(stored))
+	for _, k := range stored {
+		if !m.visible(k) || m.expired(k) {
+			continue
+		}
+		if key, ok := m.publicKey(k); ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// storageKey maps a caller-visible key to the key held in the backing map.
+func (m *MockService) storageKey(key string) string {
+	if m.Namespace == "" {
+		return key
+	}
+	return m.Namespace + ":" + key
+}
+
+// publicKey reverses storageKey, reporting false for keys that belong to
+// another namespace.
+func (m *MockService) publicKey(stored string) (string, bool) {
+	if m.Namespace == "" {
+		return stored, true
+	}
+	return strings.CutPrefix(stored, m.Namespace+":")
 }
 
 // ServiceConfig holds configuration for a service
 type ServiceConfig struct {
 	Name         string
 	Type         string
+	EnvPrefix    string
 	ResponseTime time.Duration
 	FailureRate  float32
+	DryRun       bool
+	// FailureBudget is the largest fraction of calls that may fail across
+	// the run, retries included, before the service fails the suite. Zero
+	// disables the check.
+	FailureBudget float64
+	// Budget bounds how long testing the service may take, shared across
+	// its test operations. Operations left when it runs out are skipped
+	// and the service fails. Zero means no budget.
+	Budget time.Duration
+	// InitialData is stored in the service once it connects, before any
+	// tests run, so that reads find data on a fresh service.
+	InitialData map[string]string
+	// InitJitter, when positive, delays connecting the service by a
+	// random duration up to InitJitter, so that many services do not hit
+	// a shared dependency at the same instant. Zero connects at once.
+	InitJitter time.Duration
 }
 
-// LoadServiceConfig loads service configuration from environment
-func LoadServiceConfig() []ServiceConfig {
+// LoadServiceConfig loads service configuration from environment.
+//
+// Each service's defaults can be overridden with <PREFIX>_RESPONSE_TIME
+// (a Go duration such as "300ms"), <PREFIX>_RESPONSE_MS (whole
+// milliseconds), <PREFIX>_FAILURE_RATE (a number in [0, 1]),
+// <PREFIX>_FAILURE_BUDGET (a number in [0, 1]), <PREFIX>_BUDGET (a Go
+// duration) and <PREFIX>_INITIAL_DATA (a JSON object of string keys and
+// values), where PREFIX is STORAGE, DB or API. An invalid
+// <PREFIX>_RESPONSE_TIME is an error; other invalid values are logged and
+// the default kept.
+func LoadServiceConfig() ([]ServiceConfig, error) {
 	// Simulate different services with different characteristics
-	return []ServiceConfig{
+	configs := []ServiceConfig{
 		{
 			Name:         "S3-like Storage",
 			Type:         os.Getenv("STORAGE_TYPE"),
+			EnvPrefix:    "STORAGE",
 			ResponseTime: 100 * time.Millisecond,
 			FailureRate:  0.01, // 1% failure rate
 		},
 		{
 			Name:         "Database",
 			Type:         os.Getenv("DB_TYPE"),
+			EnvPrefix:    "DB",
 			ResponseTime: 50 * time.Millisecond,
 			FailureRate:  0.02, // 2% failure rate
 		},
 		{
 			Name:         "External API",
 			Type:         os.Getenv("API_TYPE"),
+			EnvPrefix:    "API",
 			ResponseTime: 200 * time.Millisecond,
 			FailureRate:  0.05, // 5% failure rate
 		},
 	}
+
+	for i := range configs {
+		if err := applyEnvOverrides(&configs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return configs, nil
+}
+
+// applyEnvOverrides replaces cfg's response time and failure rate with any
+// values set in the environment under cfg.EnvPrefix. Invalid millisecond
+// response times and failure rates are logged as warnings and leave the
+// default in place. <PREFIX>_RESPONSE_TIME wins over <PREFIX>_RESPONSE_MS.
+func applyEnvOverrides(cfg *ServiceConfig) error {
+	if v := os.Getenv(cfg.EnvPrefix + "_RESPONSE_MS"); v != "" {
+		ms, err := strconv.Atoi(v)
+		switch {
+		case err != nil:
+			log.Printf("Warning: ignoring invalid %s_RESPONSE_MS %q, using default %v: %v", cfg.EnvPrefix, v, cfg.ResponseTime, err)
+		case ms < 0:
+			log.Printf("Warning: ignoring invalid %s_RESPONSE_MS %q, using default %v: must not be negative", cfg.EnvPrefix, v, cfg.ResponseTime)
+		default:
+			cfg.ResponseTime = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	if v := os.Getenv(cfg.EnvPrefix + "_RESPONSE_TIME"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s_RESPONSE_TIME %q: %w", cfg.EnvPrefix, v, err)
+		}
+		if d < 0 {
+			return fmt.Errorf("invalid %s_RESPONSE_TIME %q: must not be negative", cfg.EnvPrefix, v)
+		}
+		cfg.ResponseTime = d
+	}
+
+	if v := os.Getenv(cfg.EnvPrefix + "_FAILURE_RATE"); v != "" {
+		rate, err := strconv.ParseFloat(v, 32)
+		switch {
+		case err != nil:
+			log.Printf("Warning: ignoring invalid %s_FAILURE_RATE %q, using default %v: %v", cfg.EnvPrefix, v, cfg.FailureRate, err)
+		case rate < 0 || rate > 1:
+			log.Printf("Warning: ignoring invalid %s_FAILURE_RATE %q, using default %v: must be between 0 and 1", cfg.EnvPrefix, v, cfg.FailureRate)
+		default:
+			cfg.FailureRate = float32(rate)
+		}
+	}
+
+	if v := os.Getenv(cfg.EnvPrefix + "_FAILURE_BUDGET"); v != "" {
+		budget, err := strconv.ParseFloat(v, 64)
+		switch {
+		case err != nil:
+			log.Printf("Warning: ignoring invalid %s_FAILURE_BUDGET %q: %v", cfg.EnvPrefix, v, err)
+		case budget < 0 || budget > 1:
+			log.Printf("Warning: ignoring invalid %s_FAILURE_BUDGET %q: must be between 0 and 1", cfg.EnvPrefix, v)
+		default:
+			cfg.FailureBudget = budget
+		}
+	}
+
+	if v := os.Getenv(cfg.EnvPrefix + "_BUDGET"); v != "" {
+		d, err := time.ParseDuration(v)
+		switch {
+		case err != nil:
+			log.Printf("Warning: ignoring invalid %s_BUDGET %q: %v", cfg.EnvPrefix, v, err)
+		case d < 0:
+			log.Printf("Warning: ignoring invalid %s_BUDGET %q: must not be negative", cfg.EnvPrefix, v)
+		default:
+			cfg.Budget = d
+		}
+	}
+
+	if v := os.Getenv(cfg.EnvPrefix + "_INITIAL_DATA"); v != "" {
+		var data map[string]string
+		if err := json.Unmarshal([]byte(v), &data); err != nil {
+			log.Printf("Warning: ignoring invalid %s_INITIAL_DATA: %v", cfg.EnvPrefix, err)
+		} else {
+			cfg.InitialData = data
+		}
+	}
+	return nil
 }
 
 func main() {
 	// Initialize random number generator
 	// Note: As of Go 1.20, rand.Seed is deprecated and not needed
 	// The random number generator is automatically seeded
-	ctx := context.Background()
+	dryRun := flag.Bool("dry-run", false, "Skip simulated latency and failures")
+	services := flag.String("services", os.Getenv("SERVICES"), "Comma-separated service names, env prefixes or types to test (default all)")
+	metricsAddr := flag.String("metrics-addr", "", "Serve Prometheus metrics on this address (e.g. :9090) during the run")
+	initJitter := flag.Duration("init-jitter", 0, "Delay connecting each service by a random duration up to this")
+	flag.Parse()
 
-	fmt.Println("=== Integration Testing Demo ===")
-	fmt.Println("This simulates integration with external services")
-	fmt.Println()
+	configs, err := LoadServiceConfig()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	configs = selectServices(configs, *services)
+	for i := range configs {
+		configs[i].DryRun = *dryRun
+		configs[i].InitJitter = *initJitter
+	}
 
-	configs := LoadServiceConfig()
-	services := make([]ExternalService, 0, len(configs))
+	var metrics *MetricsHandler
+	var srv *http.Server
+	if *metricsAddr != "" {
+		metrics = NewMetricsHandler()
+		srv = serveMetrics(*metricsAddr, metrics)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Stdout, configs, metrics)
+	stop()
+	if srv != nil {
+		srv.Close()
+	}
+	os.Exit(code)
+}
+
+// serveMetrics serves metrics at /metrics on addr in the background.
+func serveMetrics(addr string, metrics *MetricsHandler) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics)
+	srv := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Metrics server failed: %v", err)
+		}
+	}()
+	fmt.Printf("Serving metrics on http://%s/metrics\n", addr)
+	return srv
+}
+
+// selectServices returns the configs matching the comma-separated filter.
+// Each entry is compared case-insensitively against a service's name, env
+// prefix and type, so "Database", "db" and "mock-postgres" all select the
+// database. Entries that match nothing are logged as warnings. An empty
+// filter selects every service.
+func selectServices(configs []ServiceConfig, filter string) []ServiceConfig {
+	var wanted []string
+	for _, f := range strings.Split(filter, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			wanted = append(wanted, f)
+		}
+	}
+	if len(wanted) == 0 {
+		return configs
+	}
 
-	// Initialize services
+	matched := make(map[string]bool, len(wanted))
+	var selected []ServiceConfig
 	for _, cfg := range configs {
-		if cfg.Type == "" {
-			cfg.Type = "mock"
+		typ := cfg.Type
+		if typ == "" {
+			typ = TypeMock
+		}
+		include := false
+		for _, w := range wanted {
+			if strings.EqualFold(w, cfg.Name) || strings.EqualFold(w, cfg.EnvPrefix) || strings.EqualFold(w, typ) {
+				matched[w] = true
+				include = true
+			}
+		}
+		if include {
+			selected = append(selected, cfg)
 		}
-		fmt.Printf("Initializing %s service (%s)...\n", cfg.Name, cfg.Type)
-		svc := NewMockService(cfg.Name, cfg.ResponseTime, cfg.FailureRate)
-		services = append(services, svc)
 	}
 
-	fmt.Println("\n--- Running Integration Tests ---")
+	for _, w := range wanted {
+		if !matched[w] {
+			log.Printf("Warning: no service matches %q", w)
+		}
+	}
+	return selected
+}
 
-	// Test each service
-	for i, svc := range services {
-		cfg := configs[i]
-		fmt.Printf("\nTesting %s:\n", cfg.Name)
+// serviceFailure records which step of a service's test sequence failed.
+type serviceFailure struct {
+	service  string
+	category string
+	err      error
+}
+
+// Exit codes returned by run.
+const (
+	exitOK          = 0
+	exitFailure     = 1
+	exitInterrupted = 130
+)
+
+// run exercises every configured service and returns the process exit code:
+// exitOK when all services pass, exitFailure when any of them fails and
+// exitInterrupted when ctx is canceled before the run completes. Services
+// that report metrics are registered with metrics, which may be nil. All
+// progress and failure output is written to w.
+func run(ctx context.Context, w io.Writer, configs []ServiceConfig, metrics *MetricsHandler) int {
+	fmt.Fprintln(w, "=== Integration Testing Demo ===")
+	fmt.Fprintln(w, "This simulates integration with external services")
+	fmt.Fprintln(w)
+
+	services, initialized, failures := initServices(w, configs, metrics)
+	defer closeServices(services)
 
-		// Test connection
-		if err := svc.Connect(ctx); err != nil {
-			log.Printf("  ✗ Connection failed: %v", err)
+	fmt.Fprintln(w, "\n--- Connecting Services ---")
+
+	connected := make([]ExternalService, 0, len(services))
+	connectedCfgs := make([]ServiceConfig, 0, len(initialized))
+	for i, err := range connectEach(ctx, services, initialized, connectWorkers) {
+		if err != nil {
+			fmt.Fprintf(w, "  ✗ %s: Connection failed: %v\n", initialized[i].Name, err)
+			failures = append(failures, serviceFailure{initialized[i].Name, "Connection", err})
 			continue
 		}
+		connected = append(connected, services[i])
+		connectedCfgs = append(connectedCfgs, initialized[i])
+	}
+	if ctx.Err() != nil {
+		fmt.Fprintln(w, "\n=== Shutting down: integration tests interrupted ===")
+		return exitInterrupted
+	}
 
-		// Test ping
-		if err := svc.Ping(ctx); err != nil {
-			log.Printf("  ✗ Ping failed: %v", err)
-			continue
+	var preloaded []ExternalService
+	var preloadedCfgs []ServiceConfig
+	for i, svc := range connected {
+		cfg := connectedCfgs[i]
+		if len(cfg.InitialData) > 0 {
+			if err := preload(ctx, svc, cfg.InitialData); err != nil {
+				fmt.Fprintf(w, "  ✗ %s: Preloading data failed: %v\n", cfg.Name, err)
+				failures = append(failures, serviceFailure{cfg.Name, "Preload", err})
+				continue
+			}
+			fmt.Fprintf(w, "  ✓ %s: Preloaded %d keys\n", cfg.Name, len(cfg.InitialData))
 		}
-		fmt.Printf("  ✓ Ping successful\n")
+		preloaded = append(preloaded, svc)
+		preloadedCfgs = append(preloadedCfgs, cfg)
+	}
 
-		// Test data operations
-		testKey := fmt.Sprintf("test-key-%d", time.Now().Unix())
-		testValue := fmt.Sprintf("test-value-%s", cfg.Name)
+	fmt.Fprintln(w, "\n--- Running Integration Tests ---")
 
-		if err := svc.PutData(ctx, testKey, testValue); err != nil {
-			log.Printf("  ✗ Put data failed: %v", err)
-			continue
+	testFailures, err := testServices(ctx, w, preloaded, preloadedCfgs)
+	failures = append(failures, testFailures...)
+	if err != nil {
+		fmt.Fprintln(w, "\n=== Shutting down: integration tests interrupted ===")
+		return exitInterrupted
+	}
+
+	fmt.Fprintln(w, "\n=== Integration Tests Complete ===")
+
+	if len(failures) > 0 {
+		fmt.Fprintf(w, "%d of %d services failed:\n", len(failures), len(configs))
+		for _, f := range failures {
+			fmt.Fprintf(w, "  - %s: %s failed: %v\n", f.service, f.category, f.err)
 		}
-		fmt.Printf("  ✓ Data stored successfully\n")
+		return exitFailure
+	}
+	return exitOK
+}
+
+// preload stores data in svc in key order, stopping at the first error.
+func preload(ctx context.Context, svc ExternalService, data map[string]string) error {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := svc.PutData(ctx, k, data[k]); err != nil {
+			return fmt.Errorf("failed to preload %s: %w", k, err)
+		}
+	}
+	return nil
+}
 
-		retrieved, err := svc.GetData(ctx, testKey)
+// connectWorkers bounds how many services run connects concurrently.
+const connectWorkers = 4
+
+// initServices creates a service for each config, writing progress to w,
+// and registers those reporting metrics with metrics, which may be nil.
+// It returns the created services, their configs and the services that
+// failed to initialize.
+func initServices(w io.Writer, configs []ServiceConfig, metrics *MetricsHandler) ([]ExternalService, []ServiceConfig, []serviceFailure) {
+	var failures []serviceFailure
+	services := make([]ExternalService, 0, len(configs))
+	initialized := make([]ServiceConfig, 0, len(configs))
+	for _, cfg := range configs {
+		if cfg.Type == "" {
+			cfg.Type = TypeMock
+		}
+		fmt.Fprintf(w, "Initializing %s service (%s)...\n", cfg.Name, cfg.Type)
+		svc, err := NewServiceByType(cfg)
 		if err != nil {
-			log.Printf("  ✗ Get data failed: %v", err)
+			fmt.Fprintf(w, "  ✗ Initialization failed: %v\n", err)
+			failures = append(failures, serviceFailure{cfg.Name, "Initialization", err})
 			continue
 		}
-		if retrieved != testValue {
-			log.Printf("  ✗ Data mismatch: expected %s, got %s", testValue, retrieved)
-			continue
+		if src, ok := svc.(MetricsSource); ok && metrics != nil {
+			metrics.Register(src)
+		}
+		services = append(services, svc)
+		initialized = append(initialized, cfg)
+	}
+	return services, initialized, failures
+}
+
+// closeServices closes the services that hold resources such as servers,
+// logging any error.
+func closeServices(services []ExternalService) {
+	for _, svc := range services {
+		if c, ok := svc.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				log.Printf("Warning: failed to close service: %v", err)
+			}
+		}
+	}
+}
+
+// connectAll connects every service concurrently, with at most workers
+// connects in flight. It returns the connection errors joined with
+// errors.Join, each prefixed with the failing service's name, or nil if
+// every service connected.
+func connectAll(ctx context.Context, services []ExternalService, configs []ServiceConfig, workers int) error {
+	var errs []error
+	for i, err := range connectEach(ctx, services, configs, workers) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", configs[i].Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// connectEach connects every service concurrently, with at most workers
+// connects in flight, and returns each service's connection error by index.
+// Each connect first waits up to its config's InitJitter, so the delays
+// overlap rather than add up.
+func connectEach(ctx context.Context, services []ExternalService, configs []ServiceConfig, workers int) []error {
+	if workers < 1 {
+		workers = 1
+	}
+	errs := make([]error, len(services))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, svc := range services {
+		i, svc := i, svc
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if jitter := configs[i].InitJitter; jitter > 0 {
+				select {
+				case <-time.After(time.Duration(rand.Int63n(int64(jitter)))):
+				case <-ctx.Done():
+					errs[i] = ctx.Err()
+					return
+				}
+			}
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+			errs[i] = svc.Connect(ctx)
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+// testServices runs testService against each service in turn, writing
+// progress to w. It stops as soon as ctx is done, returning the failures
+// seen so far and ctx's error.
+func testServices(ctx context.Context, w io.Writer, services []ExternalService, configs []ServiceConfig) ([]serviceFailure, error) {
+	var failures []serviceFailure
+	for i, svc := range services {
+		if err := ctx.Err(); err != nil {
+			return failures, err
 		}
-		fmt.Printf("  ✓ Data retrieved successfully\n")
+		cfg := configs[i]
+		fmt.Fprintf(w, "\nTesting %s:\n", cfg.Name)
 
-		keys, err := svc.ListKeys(ctx)
+		svcCtx, cancel := ctx, context.CancelFunc(func() {})
+		if cfg.Budget > 0 {
+			svcCtx, cancel = context.WithTimeout(ctx, cfg.Budget)
+		}
+		category, err := testService(svcCtx, w, svc, cfg)
+		cancel()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return failures, ctxErr
+		}
+		if src, ok := svc.(MetricsSource); ok && err == nil && cfg.FailureBudget > 0 {
+			if err = CheckFailureBudget(src, cfg.FailureBudget); err != nil {
+				category = "Failure budget"
+			}
+		}
 		if err != nil {
-			log.Printf("  ✗ List keys failed: %v", err)
+			fmt.Fprintf(w, "  ✗ %s failed: %v\n", category, err)
+			failures = append(failures, serviceFailure{cfg.Name, category, err})
+		}
+	}
+	return failures, nil
+}
+
+// testService runs the ping/put/get/list sequence against svc, which must
+// already be connected, writing each completed step to w. On failure it
+// returns the name of the step that failed along with the error.
+func testService(ctx context.Context, w io.Writer, svc ExternalService, cfg ServiceConfig) (string, error) {
+	testKey := fmt.Sprintf("test-key-%d", time.Now().Unix())
+	testValue := fmt.Sprintf("test-value-%s", cfg.Name)
+	var retrieved string
+
+	return runSteps(ctx, w, []testStep{
+		{"Ping", func(ctx context.Context) error {
+			if err := svc.Ping(ctx); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "  ✓ Ping successful\n")
+			return nil
+		}},
+		{"Put data", func(ctx context.Context) error {
+			if err := svc.PutData(ctx, testKey, testValue); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "  ✓ Data stored successfully\n")
+			return nil
+		}},
+		{"Get data", func(ctx context.Context) (err error) {
+			retrieved, err = svc.GetData(ctx, testKey)
+			return err
+		}},
+		{"Data verification", func(ctx context.Context) error {
+			if retrieved != testValue {
+				return fmt.Errorf("data mismatch: expected %s, got %s", testValue, retrieved)
+			}
+			fmt.Fprintf(w, "  ✓ Data retrieved successfully\n")
+			return nil
+		}},
+		{"List keys", func(ctx context.Context) error {
+			keys, err := svc.ListKeys(ctx)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "  ✓ Listed %d keys\n", len(keys))
+			return nil
+		}},
+	})
+}
+
+// testStep is one named operation of a service test.
+type testStep struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// runSteps runs steps in order, stopping at the first failure, and returns
+// the failing step's name and error. When ctx carries a deadline, the steps
+// share it as one budget: once it runs out, whether between steps or
+// during one, the remaining steps are skipped with a "budget exhausted"
+// message and the failure is reported under "Budget".
+func runSteps(ctx context.Context, w io.Writer, steps []testStep) (string, error) {
+	_, budgeted := ctx.Deadline()
+	for i, step := range steps {
+		if budgeted && ctx.Err() != nil {
+			return "Budget", skipSteps(w, steps[i:], ctx.Err())
+		}
+		err := step.run(ctx)
+		if err == nil {
 			continue
 		}
-		fmt.Printf("  ✓ Listed %d keys\n", len(keys))
+		if budgeted && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "Budget", skipSteps(w, steps[i:], fmt.Errorf("%s interrupted: %w", step.name, err))
+		}
+		return step.name, err
 	}
+	return "", nil
+}
 
-	fmt.Println("\n=== Integration Tests Complete ===")
-}
\ No newline at end of file
+// skipSteps reports steps as skipped for lack of budget and returns the
+// error describing it.
+func skipSteps(w io.Writer, steps []testStep, cause error) error {
+	names := make([]string, len(steps))
+	for i, step := range steps {
+		names[i] = step.name
+		fmt.Fprintf(w, "  - %s skipped: budget exhausted\n", step.name)
+	}
+	return fmt.Errorf("budget exhausted, skipped %s: %w", strings.Join(names, ", "), cause)
+}
@@ -0,0 +1,1441 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMockServiceNamespace(t *testing.T) {
+	ctx := context.Background()
+	shared := NewMemoryStore()
+	a := NewMockService("A", WithStore(shared), WithNamespace("a"))
+	b := NewMockService("B", WithStore(shared), WithNamespace("b"))
+
+	if err := a.PutData(ctx, "key", "from-a"); err != nil {
+		t.Fatalf("Put on A failed: %v", err)
+	}
+	if err := b.PutData(ctx, "key", "from-b"); err != nil {
+		t.Fatalf("Put on B failed: %v", err)
+	}
+
+	stored, _ := shared.Keys()
+	if len(stored) != 2 {
+		t.Fatalf("Expected 2 entries in shared store, got %d: %v", len(stored), stored)
+	}
+	if _, err := shared.Get("a:key"); err != nil {
+		t.Errorf("Expected prefixed key %q in shared store: %v", "a:key", err)
+	}
+
+	t.Run("get is isolated", func(t *testing.T) {
+		for svc, want := range map[*MockService]string{a: "from-a", b: "from-b"} {
+			got, err := svc.GetData(ctx, "key")
+			if err != nil {
+				t.Fatalf("Get on %s failed: %v", svc.name, err)
+			}
+			if got != want {
+				t.Errorf("Get on %s: expected %q, got %q", svc.name, want, got)
+			}
+		}
+	})
+
+	t.Run("list strips prefix", func(t *testing.T) {
+		keys, err := a.ListKeys(ctx)
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(keys) != 1 || keys[0] != "key" {
+			t.Errorf("Expected [key], got %v", keys)
+		}
+	})
+}
+
+func TestMockServiceGetMulti(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("mixed present and missing", func(t *testing.T) {
+		svc := NewBasicMockService("Multi", 0, 0)
+		for _, k := range []string{"a", "b"} {
+			if err := svc.PutData(ctx, k, "value-"+k); err != nil {
+				t.Fatalf("Put %s failed: %v", k, err)
+			}
+		}
+
+		got, err := svc.GetMulti(ctx, []string{"a", "missing", "b"})
+		if err != nil {
+			t.Fatalf("GetMulti failed: %v", err)
+		}
+		if len(got) != 2 || got["a"] != "value-a" || got["b"] != "value-b" {
+			t.Errorf("Unexpected result: %v", got)
+		}
+		if _, ok := got["missing"]; ok {
+			t.Error("Missing key should be omitted from the result")
+		}
+	})
+
+	t.Run("failure fails whole call", func(t *testing.T) {
+		svc := NewBasicMockService("Multi", 0, 1.0)
+		svc.store.Set("a", "value-a")
+
+		got, err := svc.GetMulti(ctx, []string{"a"})
+		if err == nil {
+			t.Fatal("Expected an error with failure rate 1.0")
+		}
+		if got != nil {
+			t.Errorf("Expected nil result on failure, got %v", got)
+		}
+	})
+}
+
+func TestRunExitCode(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		configs []ServiceConfig
+		want    int
+	}{
+		{
+			name:    "healthy services exit zero",
+			ctx:     context.Background(),
+			configs: []ServiceConfig{{Name: "Healthy", Type: "mock"}, {Name: "Also healthy", Type: TypeDatabase}},
+			want:    exitOK,
+		},
+		{
+			name: "failing service exits non-zero",
+			ctx:  context.Background(),
+			configs: []ServiceConfig{
+				{Name: "Healthy", Type: "mock"},
+				{Name: "Broken", Type: "mock", FailureRate: 1.0},
+			},
+			want: exitFailure,
+		},
+		{
+			name: "every service failing exits non-zero",
+			ctx:  context.Background(),
+			configs: []ServiceConfig{
+				{Name: "Broken", Type: "mock", FailureRate: 1.0},
+				{Name: "Also broken", Type: TypeAPI, FailureRate: 1.0},
+			},
+			want: exitFailure,
+		},
+		{
+			name:    "unknown service type exits non-zero",
+			ctx:     context.Background(),
+			configs: []ServiceConfig{{Name: "Unknown", Type: "mock-ftp"}},
+			want:    exitFailure,
+		},
+		{
+			name:    "interrupted run",
+			ctx:     canceled,
+			configs: []ServiceConfig{{Name: "Healthy", Type: "mock"}},
+			want:    exitInterrupted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := run(tt.ctx, io.Discard, tt.configs, nil); code != tt.want {
+				t.Errorf("Expected exit code %d, got %d", tt.want, code)
+			}
+		})
+	}
+}
+
+func TestTestServicesFailureBudget(t *testing.T) {
+	ctx := context.Background()
+	cfg := ServiceConfig{Name: "Flaky", FailureBudget: 0.1}
+	flaky := NewMockService("Flaky", WithSeed(1))
+	// Record failed attempts that a retrying caller would have hidden.
+	flaky.failureRate = 1.0
+	for i := 0; i < 5; i++ {
+		_ = flaky.Ping(ctx)
+	}
+	flaky.failureRate = 0
+
+	failures, err := testServices(ctx, io.Discard, []ExternalService{flaky}, []ServiceConfig{cfg})
+	if err != nil {
+		t.Fatalf("testServices failed: %v", err)
+	}
+	if len(failures) != 1 || failures[0].category != "Failure budget" {
+		t.Fatalf("Expected a failure budget failure, got %+v", failures)
+	}
+
+	cfg.FailureBudget = 0
+	if failures, _ := testServices(ctx, io.Discard, []ExternalService{flaky}, []ServiceConfig{cfg}); len(failures) != 0 {
+		t.Errorf("Expected no failures with the budget disabled, got %+v", failures)
+	}
+}
+
+func TestTestServicesBudget(t *testing.T) {
+	ctx := context.Background()
+	slow := NewMockService("Slow", WithResponseTime(40*time.Millisecond))
+
+	var buf bytes.Buffer
+	cfg := ServiceConfig{Name: "Slow", Budget: 50 * time.Millisecond}
+	start := time.Now()
+	failures, err := testServices(ctx, &buf, []ExternalService{slow}, []ServiceConfig{cfg})
+	if err != nil {
+		t.Fatalf("testServices failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("Expected the budget to bound the test to about 50ms, took %v", elapsed)
+	}
+	if len(failures) != 1 || failures[0].category != "Budget" || !errors.Is(failures[0].err, context.DeadlineExceeded) {
+		t.Fatalf("Expected a budget failure, got %+v", failures)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"  ✓ Ping successful",
+		"  - Get data skipped: budget exhausted",
+		"  - List keys skipped: budget exhausted",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Listed") {
+		t.Errorf("Expected List keys not to run, got:\n%s", out)
+	}
+
+	buf.Reset()
+	cfg.Budget = 5 * time.Second
+	if failures, err := testServices(ctx, &buf, []ExternalService{slow}, []ServiceConfig{cfg}); err != nil || len(failures) != 0 {
+		t.Fatalf("Expected a generous budget to let every operation run, got %+v, %v\n%s", failures, err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "  ✓ Listed 1 keys") {
+		t.Errorf("Expected every operation to run, got:\n%s", buf.String())
+	}
+}
+
+func TestRunStepsBudgetBetweenSteps(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	var ran []string
+	step := func(name string, d time.Duration) testStep {
+		return testStep{name, func(context.Context) error {
+			ran = append(ran, name)
+			time.Sleep(d) // ignores ctx, finishing past the budget
+			return nil
+		}}
+	}
+	var buf bytes.Buffer
+	category, err := runSteps(ctx, &buf, []testStep{step("first", 20*time.Millisecond), step("second", 0), step("third", 0)})
+	if category != "Budget" || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a budget failure, got %q, %v", category, err)
+	}
+	if len(ran) != 1 {
+		t.Errorf("Expected only the first step to run, ran %v", ran)
+	}
+	if !strings.Contains(err.Error(), "skipped second, third") {
+		t.Errorf("Expected the error to name the skipped steps, got %v", err)
+	}
+
+	// Without a deadline a step's own failure is reported as is.
+	failing := testStep{"broken", func(context.Context) error { return ErrTransient }}
+	if category, err := runSteps(context.Background(), io.Discard, []testStep{failing}); category != "broken" || err != ErrTransient {
+		t.Errorf("Expected the step's failure, got %q, %v", category, err)
+	}
+}
+
+func TestRunOutput(t *testing.T) {
+	configs := []ServiceConfig{
+		{Name: "Healthy", Type: "mock", DryRun: true},
+		{Name: "Broken", Type: "mock", FailureRate: 1.0},
+	}
+
+	var buf bytes.Buffer
+	if code := run(context.Background(), &buf, configs, nil); code != exitFailure {
+		t.Errorf("Expected exit code %d, got %d", exitFailure, code)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"=== Integration Testing Demo ===",
+		"Initializing Healthy service (mock)...",
+		"Initializing Broken service (mock)...",
+		"  ✗ Broken: Connection failed: ",
+		"Testing Healthy:",
+		"  ✓ Ping successful",
+		"  ✓ Data stored successfully",
+		"  ✓ Data retrieved successfully",
+		"  ✓ Listed 1 keys",
+		"=== Integration Tests Complete ===",
+		"1 of 2 services failed:",
+		"  - Broken: Connection failed: ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Testing Broken:") {
+		t.Errorf("Expected a service that failed to connect not to be tested, got:\n%s", out)
+	}
+}
+
+func TestPreload(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Warm", WithDryRun(true))
+	data := map[string]string{"users/alice": "admin", "users/bob": "viewer"}
+	if err := preload(ctx, svc, data); err != nil {
+		t.Fatalf("preload failed: %v", err)
+	}
+	for k, want := range data {
+		if got, err := svc.GetData(ctx, k); err != nil || got != want {
+			t.Errorf("GetData(%q) = %q, %v; want %q", k, got, err, want)
+		}
+	}
+
+	strict := NewMockService("Strict", WithMaxValueSize(5))
+	err := preload(ctx, strict, map[string]string{"a": "ok", "b": "too long", "c": "ok"})
+	if !errors.Is(err, ErrValueTooLarge) || !strings.Contains(err.Error(), "preload b") {
+		t.Errorf("Expected preloading b to fail with ErrValueTooLarge, got %v", err)
+	}
+	if _, err := strict.GetData(ctx, "c"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected preloading to stop at the first error, got %v", err)
+	}
+}
+
+func TestRunPreloadsInitialData(t *testing.T) {
+	configs := []ServiceConfig{
+		{Name: "Warm", Type: "mock", DryRun: true, InitialData: map[string]string{"a": "1", "b": "2"}},
+		{Name: "Oversized", Type: TypeDatabase, DryRun: true, InitialData: map[string]string{
+			"row": strings.Repeat("x", databaseMaxValueSize+1),
+		}},
+	}
+
+	var buf bytes.Buffer
+	if code := run(context.Background(), &buf, configs, nil); code != exitFailure {
+		t.Errorf("Expected exit code %d, got %d", exitFailure, code)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"  ✓ Warm: Preloaded 2 keys",
+		"  ✗ Oversized: Preloading data failed: ",
+		"Testing Warm:",
+		"  ✓ Listed 3 keys",
+		"  - Oversized: Preload failed: ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Testing Oversized:") {
+		t.Errorf("Expected a service that failed to preload not to be tested, got:\n%s", out)
+	}
+}
+
+func TestMockServiceDryRun(t *testing.T) {
+	ctx := context.Background()
+	svc := NewBasicMockService("DryRun", time.Second, 1.0)
+	svc.DryRun = true
+
+	start := time.Now()
+	if err := svc.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed in dry-run mode: %v", err)
+	}
+	if err := svc.PutData(ctx, "key", "value"); err != nil {
+		t.Fatalf("Put failed in dry-run mode: %v", err)
+	}
+	if got, err := svc.GetData(ctx, "key"); err != nil || got != "value" {
+		t.Fatalf("Get in dry-run mode: got %q, %v", got, err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Dry-run operations took %v, expected near-instant", elapsed)
+	}
+}
+
+func TestMockServiceOnOperation(t *testing.T) {
+	type call struct {
+		op, key string
+		dur     time.Duration
+		err     error
+	}
+	ctx := context.Background()
+	svc := NewBasicMockService("Hooked", 5*time.Millisecond, 0)
+
+	var calls []call
+	svc.OnOperation = func(op, key string, dur time.Duration, err error) {
+		// Taking the lock here would deadlock if the hook ran under it.
+		svc.mu.Lock()
+		svc.mu.Unlock()
+		calls = append(calls, call{op, key, dur, err})
+	}
+
+	if err := svc.PutData(ctx, "key", "value"); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, err := svc.GetData(ctx, "key"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	svc.failureRate = 1.0
+	getErr := func() error { _, err := svc.GetData(ctx, "key"); return err }()
+	if getErr == nil {
+		t.Fatal("Expected Get to fail with failure rate 1.0")
+	}
+
+	want := []call{
+		{op: "PutData", key: "key"},
+		{op: "GetData", key: "key"},
+		{op: "GetData", key: "key", err: getErr},
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("Expected %d hook calls, got %d: %+v", len(want), len(calls), calls)
+	}
+	for i, w := range want {
+		got := calls[i]
+		if got.op != w.op || got.key != w.key || got.err != w.err {
+			t.Errorf("Call %d: expected %s(%q) err=%v, got %s(%q) err=%v",
+				i, w.op, w.key, w.err, got.op, got.key, got.err)
+		}
+		if got.dur < svc.responseTime {
+			t.Errorf("Call %d: duration %v shorter than response time %v", i, got.dur, svc.responseTime)
+		}
+	}
+}
+
+func TestMockServiceWarmup(t *testing.T) {
+	ctx := context.Background()
+	const responseTime = 10 * time.Millisecond
+	svc := NewBasicMockService("Warmup", responseTime, 0)
+	svc.WarmupCalls = 3
+	svc.WarmupFactor = 5
+
+	if err := svc.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	durations := make([]time.Duration, 6)
+	for i := range durations {
+		start := time.Now()
+		if err := svc.PutData(ctx, "key", "value"); err != nil {
+			t.Fatalf("Put %d failed: %v", i, err)
+		}
+		durations[i] = time.Since(start)
+	}
+
+	if durations[0] < 4*responseTime {
+		t.Errorf("First call took %v, expected at least %v", durations[0], 4*responseTime)
+	}
+	if durations[1] >= durations[0] {
+		t.Errorf("Expected warmup to decay: call 0 took %v, call 1 took %v", durations[0], durations[1])
+	}
+	for i, d := range durations[3:] {
+		if d < responseTime || d > 3*responseTime {
+			t.Errorf("Call %d took %v, expected close to %v", i+3, d, responseTime)
+		}
+	}
+}
+
+func TestLoadServiceConfigEnvOverrides(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		configs, err := LoadServiceConfig()
+		if err != nil {
+			t.Fatalf("LoadServiceConfig failed: %v", err)
+		}
+		if configs[0].ResponseTime != 100*time.Millisecond || configs[0].FailureRate != 0.01 {
+			t.Errorf("Unexpected storage defaults: %+v", configs[0])
+		}
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		t.Setenv("STORAGE_RESPONSE_TIME", "300ms")
+		t.Setenv("STORAGE_FAILURE_RATE", "0.1")
+		t.Setenv("API_FAILURE_RATE", "0")
+		t.Setenv("DB_FAILURE_BUDGET", "0.25")
+		t.Setenv("API_INITIAL_DATA", `{"users/alice": "admin"}`)
+		t.Setenv("STORAGE_BUDGET", "2s")
+
+		configs, err := LoadServiceConfig()
+		if err != nil {
+			t.Fatalf("LoadServiceConfig failed: %v", err)
+		}
+		if got := configs[0].ResponseTime; got != 300*time.Millisecond {
+			t.Errorf("Expected storage response time 300ms, got %v", got)
+		}
+		if got := configs[0].FailureRate; got != 0.1 {
+			t.Errorf("Expected storage failure rate 0.1, got %v", got)
+		}
+		if got := configs[1].ResponseTime; got != 50*time.Millisecond {
+			t.Errorf("Expected database response time to keep its default, got %v", got)
+		}
+		if got := configs[2].FailureRate; got != 0 {
+			t.Errorf("Expected API failure rate 0, got %v", got)
+		}
+		if got := configs[1].FailureBudget; got != 0.25 {
+			t.Errorf("Expected database failure budget 0.25, got %v", got)
+		}
+		if got := configs[2].InitialData; len(got) != 1 || got["users/alice"] != "admin" {
+			t.Errorf("Expected API initial data {users/alice: admin}, got %v", got)
+		}
+		if got := configs[0].Budget; got != 2*time.Second {
+			t.Errorf("Expected storage budget 2s, got %v", got)
+		}
+		if got := configs[0].InitialData; got != nil {
+			t.Errorf("Expected no storage initial data, got %v", got)
+		}
+	})
+
+	invalid := []struct {
+		name, env, value string
+	}{
+		{"unparseable duration", "DB_RESPONSE_TIME", "fast"},
+		{"negative duration", "DB_RESPONSE_TIME", "-1s"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.env, tt.value)
+			_, err := LoadServiceConfig()
+			if err == nil {
+				t.Fatalf("Expected an error for %s=%q", tt.env, tt.value)
+			}
+			if !strings.Contains(err.Error(), tt.env) {
+				t.Errorf("Expected error to name %s, got %v", tt.env, err)
+			}
+		})
+	}
+
+	t.Run("invalid initial data", func(t *testing.T) {
+		t.Setenv("DB_INITIAL_DATA", `{"key": 1}`)
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+		configs, err := LoadServiceConfig()
+		if err != nil {
+			t.Fatalf("Expected invalid initial data to be ignored, got %v", err)
+		}
+		if got := configs[1].InitialData; got != nil {
+			t.Errorf("Expected no database initial data, got %v", got)
+		}
+		if !strings.Contains(buf.String(), "Warning") || !strings.Contains(buf.String(), "DB_INITIAL_DATA") {
+			t.Errorf("Expected a warning naming DB_INITIAL_DATA, got %q", buf.String())
+		}
+	})
+
+	t.Run("response ms", func(t *testing.T) {
+		t.Setenv("DB_RESPONSE_MS", "250")
+
+		configs, err := LoadServiceConfig()
+		if err != nil {
+			t.Fatalf("LoadServiceConfig failed: %v", err)
+		}
+		if got := configs[1].ResponseTime; got != 250*time.Millisecond {
+			t.Errorf("Expected database response time 250ms, got %v", got)
+		}
+		if got := configs[0].ResponseTime; got != 100*time.Millisecond {
+			t.Errorf("Expected storage response time to keep its default, got %v", got)
+		}
+	})
+
+	t.Run("response time wins over response ms", func(t *testing.T) {
+		t.Setenv("API_RESPONSE_MS", "10")
+		t.Setenv("API_RESPONSE_TIME", "1s")
+
+		configs, err := LoadServiceConfig()
+		if err != nil {
+			t.Fatalf("LoadServiceConfig failed: %v", err)
+		}
+		if got := configs[2].ResponseTime; got != time.Second {
+			t.Errorf("Expected API response time 1s, got %v", got)
+		}
+	})
+
+	invalidMS := []struct {
+		name, env, value string
+	}{
+		{"unparseable ms", "STORAGE_RESPONSE_MS", "fast"},
+		{"fractional ms", "STORAGE_RESPONSE_MS", "1.5"},
+		{"negative ms", "STORAGE_RESPONSE_MS", "-5"},
+	}
+	for _, tt := range invalidMS {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.env, tt.value)
+			var buf bytes.Buffer
+			log.SetOutput(&buf)
+			t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+			configs, err := LoadServiceConfig()
+			if err != nil {
+				t.Fatalf("Expected an invalid response time to be ignored, got %v", err)
+			}
+			if got := configs[0].ResponseTime; got != 100*time.Millisecond {
+				t.Errorf("Expected storage response time to keep its default 100ms, got %v", got)
+			}
+			if !strings.Contains(buf.String(), "Warning") || !strings.Contains(buf.String(), tt.env) {
+				t.Errorf("Expected a warning naming %s, got %q", tt.env, buf.String())
+			}
+		})
+	}
+
+	invalidRates := []struct {
+		name, env, value string
+	}{
+		{"unparseable rate", "API_FAILURE_RATE", "often"},
+		{"rate out of range", "API_FAILURE_RATE", "1.5"},
+		{"negative rate", "API_FAILURE_RATE", "-0.1"},
+		{"unparseable budget", "API_FAILURE_BUDGET", "some"},
+		{"budget out of range", "API_FAILURE_BUDGET", "2"},
+		{"unparseable time budget", "API_BUDGET", "soon"},
+		{"negative time budget", "API_BUDGET", "-1s"},
+	}
+	for _, tt := range invalidRates {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.env, tt.value)
+			var buf bytes.Buffer
+			log.SetOutput(&buf)
+			t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+			configs, err := LoadServiceConfig()
+			if err != nil {
+				t.Fatalf("Expected an invalid failure rate to be ignored, got %v", err)
+			}
+			if got := configs[2].FailureRate; got != 0.05 {
+				t.Errorf("Expected API failure rate to keep its default 0.05, got %v", got)
+			}
+			if got := configs[2].FailureBudget; got != 0 {
+				t.Errorf("Expected API failure budget to stay disabled, got %v", got)
+			}
+			if !strings.Contains(buf.String(), "Warning") || !strings.Contains(buf.String(), tt.env) {
+				t.Errorf("Expected a warning naming %s, got %q", tt.env, buf.String())
+			}
+		})
+	}
+}
+
+func TestMockServiceGetDataOrDefault(t *testing.T) {
+	ctx := context.Background()
+	svc := NewBasicMockService("Default", 0, 0)
+	svc.store.Set("present", "value")
+
+	t.Run("missing key returns default", func(t *testing.T) {
+		got, err := svc.GetDataOrDefault(ctx, "missing", "fallback")
+		if err != nil || got != "fallback" {
+			t.Errorf("Expected fallback, got %q, %v", got, err)
+		}
+	})
+
+	t.Run("present key returns value", func(t *testing.T) {
+		got, err := svc.GetDataOrDefault(ctx, "present", "fallback")
+		if err != nil || got != "value" {
+			t.Errorf("Expected value, got %q, %v", got, err)
+		}
+	})
+
+	t.Run("failure still errors", func(t *testing.T) {
+		failing := NewBasicMockService("Default", 0, 1.0)
+		got, err := failing.GetDataOrDefault(ctx, "missing", "fallback")
+		if err == nil {
+			t.Fatalf("Expected an error, got %q", got)
+		}
+		if got == "fallback" {
+			t.Error("Default must not be returned for a failed operation")
+		}
+	})
+}
+
+func TestMockServicePoolSize(t *testing.T) {
+	const responseTime = 50 * time.Millisecond
+
+	t.Run("operations serialize", func(t *testing.T) {
+		svc := NewBasicMockService("Pool", responseTime, 0)
+		svc.PoolSize = 1
+
+		start := time.Now()
+		var wg sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				svc.GetData(context.Background(), "key")
+			}()
+		}
+		wg.Wait()
+
+		if elapsed := time.Since(start); elapsed < 2*responseTime {
+			t.Errorf("Two Gets with PoolSize=1 took %v, expected at least %v", elapsed, 2*responseTime)
+		}
+	})
+
+	t.Run("canceled waiter returns promptly", func(t *testing.T) {
+		svc := NewBasicMockService("Pool", responseTime, 0)
+		svc.PoolSize = 1
+
+		// Occupy the only connection.
+		go svc.GetData(context.Background(), "key")
+		time.Sleep(responseTime / 5)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err := svc.GetData(ctx, "key")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context deadline error, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > responseTime/2 {
+			t.Errorf("Canceled waiter took %v to return", elapsed)
+		}
+	})
+
+	t.Run("waiter times out", func(t *testing.T) {
+		ctx := context.Background()
+		clock := NewFakeClock(clockEpoch)
+		svc := NewMockService("Pool", WithClock(clock), WithResponseTime(10*time.Second),
+			WithPoolSize(2), WithPoolTimeout(time.Second))
+
+		// Saturate the pool with two long operations.
+		held := make(chan error, 2)
+		for i := 0; i < 2; i++ {
+			go func() { held <- svc.Ping(ctx) }()
+		}
+		for clock.Waiters() < 2 {
+			time.Sleep(time.Millisecond)
+		}
+
+		waited := make(chan error, 1)
+		go func() { waited <- svc.Ping(ctx) }()
+		for clock.Waiters() < 3 {
+			time.Sleep(time.Millisecond)
+		}
+		clock.Advance(time.Second)
+		if err := <-waited; !errors.Is(err, ErrPoolExhausted) {
+			t.Fatalf("Expected the third caller to get ErrPoolExhausted, got %v", err)
+		}
+
+		clock.Advance(9 * time.Second)
+		for i := 0; i < 2; i++ {
+			if err := <-held; err != nil {
+				t.Fatal(err)
+			}
+		}
+		go func() { waited <- svc.Ping(ctx) }()
+		for clock.Waiters() < 1 {
+			time.Sleep(time.Millisecond)
+		}
+		clock.Advance(5 * time.Second)
+		if err := <-waited; err != nil {
+			t.Errorf("Expected a free connection once the pool drained, got %v", err)
+		}
+	})
+}
+
+func TestMockServiceMaxConcurrency(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("excess operations wait", func(t *testing.T) {
+		clock := NewFakeClock(clockEpoch)
+		svc := NewMockService("Throttled", WithClock(clock), WithResponseTime(time.Second), WithMaxConcurrency(2))
+
+		// Only operations holding a slot sleep on the clock, so its waiters
+		// are the operations in flight.
+		const calls = 5
+		done := make(chan error, calls)
+		for i := 0; i < calls; i++ {
+			go func() { done <- svc.Ping(ctx) }()
+		}
+		maxInFlight := 0
+		for remaining := calls; remaining > 0; {
+			for clock.Waiters() == 0 {
+				time.Sleep(time.Millisecond)
+			}
+			// Give the waiting callers a chance to exceed the cap.
+			time.Sleep(5 * time.Millisecond)
+			n := clock.Waiters()
+			maxInFlight = max(maxInFlight, n)
+			clock.Advance(time.Second)
+			for i := 0; i < n; i++ {
+				if err := <-done; err != nil {
+					t.Fatal(err)
+				}
+			}
+			remaining -= n
+		}
+		if maxInFlight != 2 {
+			t.Errorf("Expected at most 2 operations in flight, got %d", maxInFlight)
+		}
+	})
+
+	t.Run("reject when busy", func(t *testing.T) {
+		clock := NewFakeClock(clockEpoch)
+		svc := NewMockService("Throttled", WithClock(clock), WithResponseTime(time.Second),
+			WithMaxConcurrency(1), WithRejectWhenBusy(true))
+
+		held := make(chan error, 1)
+		go func() { held <- svc.Ping(ctx) }()
+		for clock.Waiters() < 1 {
+			time.Sleep(time.Millisecond)
+		}
+		if err := svc.Ping(ctx); !errors.Is(err, ErrBusy) {
+			t.Errorf("Expected ErrBusy beyond the cap, got %v", err)
+		}
+		clock.Advance(time.Second)
+		if err := <-held; err != nil {
+			t.Fatal(err)
+		}
+		go func() { held <- svc.Ping(ctx) }()
+		for clock.Waiters() < 1 {
+			time.Sleep(time.Millisecond)
+		}
+		clock.Advance(time.Second)
+		if err := <-held; err != nil {
+			t.Errorf("Expected a free slot once the operation finished, got %v", err)
+		}
+	})
+}
+
+func TestMockServiceExists(t *testing.T) {
+	ctx := context.Background()
+	svc := NewBasicMockService("Exists", 0, 0)
+	svc.store.Set("present", "value")
+
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"present", true},
+		{"missing", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := svc.Exists(ctx, tt.key)
+			if err != nil {
+				t.Fatalf("Exists failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Exists(%q) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("failure", func(t *testing.T) {
+		failing := NewBasicMockService("Exists", 0, 1.0)
+		failing.store.Set("present", "value")
+		if ok, err := failing.Exists(ctx, "present"); err == nil || ok {
+			t.Errorf("Expected failure, got %v, %v", ok, err)
+		}
+	})
+}
+
+func TestTestServicesStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	configs := []ServiceConfig{{Name: "First"}, {Name: "Second"}, {Name: "Third"}}
+	services := make([]ExternalService, len(configs))
+	touched := make([]bool, len(configs))
+	for i, cfg := range configs {
+		i := i
+		svc := NewBasicMockService(cfg.Name, 10*time.Millisecond, 0)
+		svc.OnOperation = func(op, key string, dur time.Duration, err error) {
+			touched[i] = true
+			if i == 0 && op == "Ping" {
+				cancel()
+			}
+		}
+		services[i] = svc
+	}
+
+	start := time.Now()
+	_, err := testServices(ctx, io.Discard, services, configs)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Loop took %v to stop after cancellation", elapsed)
+	}
+	if touched[1] || touched[2] {
+		t.Errorf("Expected remaining services to be skipped, touched: %v", touched)
+	}
+}
+
+func TestMockServiceHonorsCancellation(t *testing.T) {
+	svc := NewBasicMockService("Slow", time.Minute, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := svc.GetData(ctx, "key"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetData took %v to return after cancellation", elapsed)
+	}
+}
+
+func TestMockServiceDeleteData(t *testing.T) {
+	ctx := context.Background()
+	svc := NewBasicMockService("Delete", 0, 0)
+	svc.store.Set("key", "value")
+
+	if err := svc.DeleteData(ctx, "key"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := svc.store.Get("key"); err == nil {
+		t.Error("Expected key to be removed")
+	}
+	if err := svc.DeleteData(ctx, "key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting a missing key, got %v", err)
+	}
+}
+
+func TestMockServiceDeletePrefix(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Prefix")
+	for _, k := range []string{"tmp/a", "tmp/b", "tmp/c", "keep/a", "keep/tmp/b"} {
+		svc.store.Set(k, "value")
+	}
+
+	deleted, err := svc.DeletePrefix(ctx, "tmp/")
+	if err != nil {
+		t.Fatalf("DeletePrefix failed: %v", err)
+	}
+	if deleted != 3 {
+		t.Errorf("Expected 3 deleted keys, got %d", deleted)
+	}
+	keys, _ := svc.store.Keys()
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "keep/a" || keys[1] != "keep/tmp/b" {
+		t.Errorf("Expected only keep/ keys to remain, got %v", keys)
+	}
+
+	if deleted, err := svc.DeletePrefix(ctx, ""); !errors.Is(err, ErrPermanent) || deleted != 0 {
+		t.Errorf("Expected an empty prefix to be rejected, got %d, %v", deleted, err)
+	}
+	if keys, _ := svc.store.Keys(); len(keys) != 2 {
+		t.Errorf("Expected an empty prefix to delete nothing, got %v", keys)
+	}
+
+	t.Run("concurrent", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(2)
+			go func() { defer wg.Done(); svc.PutData(ctx, "race/key", "v") }()
+			go func() { defer wg.Done(); svc.DeletePrefix(ctx, "race/") }()
+		}
+		wg.Wait()
+	})
+}
+
+func TestMockServiceColdStartDelay(t *testing.T) {
+	ctx := context.Background()
+	const (
+		responseTime = 10 * time.Millisecond
+		coldStart    = 50 * time.Millisecond
+	)
+	svc := NewMockService("Cold", WithResponseTime(responseTime), WithColdStartDelay(coldStart))
+
+	timeConnect := func() time.Duration {
+		start := time.Now()
+		if err := svc.Connect(ctx); err != nil {
+			t.Fatalf("Connect failed: %v", err)
+		}
+		return time.Since(start)
+	}
+
+	if first := timeConnect(); first < responseTime+coldStart {
+		t.Errorf("First Connect took %v, expected at least %v", first, responseTime+coldStart)
+	}
+	for i := 0; i < 2; i++ {
+		if d := timeConnect(); d < responseTime || d >= responseTime+coldStart {
+			t.Errorf("Subsequent Connect took %v, expected about %v", d, responseTime)
+		}
+	}
+}
+
+func TestMockServiceConnectTime(t *testing.T) {
+	ctx := context.Background()
+	const (
+		responseTime = 5 * time.Millisecond
+		connectTime  = 50 * time.Millisecond
+	)
+	timed := func(fn func() error) time.Duration {
+		t.Helper()
+		start := time.Now()
+		if err := fn(); err != nil {
+			t.Fatalf("Operation failed: %v", err)
+		}
+		return time.Since(start)
+	}
+
+	svc := NewMockService("Slow connect", WithResponseTime(responseTime), WithConnectTime(connectTime))
+	if d := timed(func() error { return svc.Connect(ctx) }); d < connectTime {
+		t.Errorf("Connect took %v, expected at least %v", d, connectTime)
+	}
+	if d := timed(func() error { return svc.PutData(ctx, "key", "value") }); d < responseTime || d >= connectTime {
+		t.Errorf("PutData took %v, expected about %v", d, responseTime)
+	}
+	if d := timed(func() error { _, err := svc.GetData(ctx, "key"); return err }); d < responseTime || d >= connectTime {
+		t.Errorf("GetData took %v, expected about %v", d, responseTime)
+	}
+
+	// Without a ConnectTime, Connect takes the response time; an explicit
+	// zero makes it instant.
+	if d := timed(func() error { return NewMockService("Default", WithResponseTime(connectTime)).Connect(ctx) }); d < connectTime {
+		t.Errorf("Default Connect took %v, expected at least %v", d, connectTime)
+	}
+	instant := NewMockService("Instant", WithResponseTime(connectTime), WithConnectTime(0))
+	if d := timed(func() error { return instant.Connect(ctx) }); d >= connectTime {
+		t.Errorf("Connect with a zero ConnectTime took %v", d)
+	}
+}
+
+func TestMockServiceReadinessDelay(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(clockEpoch)
+	svc := NewMockService("Starting", WithClock(clock), WithReadinessDelay(30*time.Second))
+
+	err := svc.Connect(ctx)
+	if !errors.Is(err, ErrNotReady) || !IsRetryable(err) {
+		t.Fatalf("Expected a retryable ErrNotReady at startup, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "ready in 30s") {
+		t.Errorf("Expected the error to say when the service is ready, got %v", err)
+	}
+
+	clock.Advance(29 * time.Second)
+	if err := svc.Connect(ctx); !errors.Is(err, ErrNotReady) {
+		t.Fatalf("Expected ErrNotReady just before the deadline, got %v", err)
+	}
+	clock.Advance(time.Second)
+	if err := svc.Connect(ctx); err != nil {
+		t.Fatalf("Expected Connect to succeed once ready, got %v", err)
+	}
+
+	// Dry runs skip the wait, like other simulated failures.
+	if err := NewMockService("Dry", WithReadinessDelay(time.Hour), WithDryRun(true)).Connect(ctx); err != nil {
+		t.Errorf("Expected a dry run to connect immediately, got %v", err)
+	}
+}
+
+func TestSelectServices(t *testing.T) {
+	configs, err := LoadServiceConfig()
+	if err != nil {
+		t.Fatalf("LoadServiceConfig failed: %v", err)
+	}
+	names := func(cfgs []ServiceConfig) string {
+		var out []string
+		for _, cfg := range cfgs {
+			out = append(out, cfg.Name)
+		}
+		return strings.Join(out, ",")
+	}
+
+	tests := []struct {
+		filter string
+		want   string
+	}{
+		{"", "S3-like Storage,Database,External API"},
+		{"Database", "Database"},
+		{"database", "Database"},
+		{"db", "Database"},
+		{" API , storage ", "S3-like Storage,External API"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			if got := names(selectServices(configs, tt.filter)); got != tt.want {
+				t.Errorf("selectServices(%q) = %s, want %s", tt.filter, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("by type", func(t *testing.T) {
+		t.Setenv("DB_TYPE", TypeDatabase)
+		configs, err := LoadServiceConfig()
+		if err != nil {
+			t.Fatalf("LoadServiceConfig failed: %v", err)
+		}
+		if got := names(selectServices(configs, TypeDatabase)); got != "Database" {
+			t.Errorf("Expected only the database, got %s", got)
+		}
+	})
+
+	t.Run("unknown name warns", func(t *testing.T) {
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+		if got := names(selectServices(configs, "Database,Queue")); got != "Database" {
+			t.Errorf("Expected only the database, got %s", got)
+		}
+		if !strings.Contains(buf.String(), `Warning: no service matches "Queue"`) {
+			t.Errorf("Expected a warning for Queue, got %q", buf.String())
+		}
+	})
+}
+
+func TestConnectAll(t *testing.T) {
+	ctx := context.Background()
+	configs := []ServiceConfig{
+		{Name: "Alpha"}, {Name: "Bravo"}, {Name: "Charlie"}, {Name: "Delta"}, {Name: "Echo"},
+	}
+	failing := map[string]bool{"Bravo": true, "Delta": true}
+
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	services := make([]ExternalService, len(configs))
+	for i, cfg := range configs {
+		var rate float32
+		if failing[cfg.Name] {
+			rate = 1.0
+		}
+		svc := NewMockService(cfg.Name, WithResponseTime(20*time.Millisecond), WithFailureRate(rate))
+		services[i] = &trackingService{ExternalService: svc, enter: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			inFlight++
+			maxInFlight = max(maxInFlight, inFlight)
+		}, exit: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			inFlight--
+		}}
+	}
+
+	err := connectAll(ctx, services, configs, 2)
+	if err == nil {
+		t.Fatal("Expected connectAll to fail")
+	}
+	for _, cfg := range configs {
+		named := strings.Contains(err.Error(), cfg.Name+":")
+		if failing[cfg.Name] && !named {
+			t.Errorf("Expected the error to name %s, got %v", cfg.Name, err)
+		}
+		if !failing[cfg.Name] && named {
+			t.Errorf("Expected the error not to name %s, got %v", cfg.Name, err)
+		}
+	}
+	if !errors.Is(err, ErrTransient) {
+		t.Errorf("Expected the joined error to wrap ErrTransient, got %v", err)
+	}
+	if maxInFlight > 2 {
+		t.Errorf("Expected at most 2 concurrent connects, saw %d", maxInFlight)
+	}
+
+	if err := connectAll(ctx, services[:1], configs[:1], 2); err != nil {
+		t.Errorf("Expected healthy services to connect, got %v", err)
+	}
+}
+
+// trackingService calls enter and exit around each Connect.
+type trackingService struct {
+	ExternalService
+	enter, exit func()
+}
+
+func (s *trackingService) Connect(ctx context.Context) error {
+	s.enter()
+	defer s.exit()
+	return s.ExternalService.Connect(ctx)
+}
+
+func TestMockServiceKeyRules(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Strict",
+		WithMaxKeyLength(16),
+		WithKeyPattern(regexp.MustCompile(`^[a-z0-9/_-]+$`)),
+	)
+
+	tests := []struct {
+		name  string
+		key   string
+		valid bool
+	}{
+		{"simple", "user-1", true},
+		{"path", "images/logo_2", true},
+		{"at max length", strings.Repeat("k", 16), true},
+		{"over max length", strings.Repeat("k", 17), false},
+		{"disallowed characters", "user 1!", false},
+		{"uppercase", "User", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.PutData(ctx, tt.key, "value")
+			if tt.valid {
+				if err != nil {
+					t.Fatalf("Expected %q to be accepted, got %v", tt.key, err)
+				}
+				if got, err := svc.GetData(ctx, tt.key); err != nil || got != "value" {
+					t.Errorf("Expected %q to be stored, got %q (%v)", tt.key, got, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidKey) {
+				t.Fatalf("Expected ErrInvalidKey for %q, got %v", tt.key, err)
+			}
+			if ok, _ := svc.Exists(ctx, tt.key); ok {
+				t.Errorf("Expected rejected key %q not to be stored", tt.key)
+			}
+		})
+	}
+
+	t.Run("no restrictions by default", func(t *testing.T) {
+		key := strings.Repeat("Any key! ", 100)
+		if err := NewMockService("Lenient").PutData(ctx, key, "value"); err != nil {
+			t.Errorf("Expected any key to be accepted by default, got %v", err)
+		}
+	})
+}
+
+func TestMockServiceReplicationLag(t *testing.T) {
+	ctx := context.Background()
+	lag := 30 * time.Millisecond
+	svc := NewMockService("Eventual", WithReplicationLag(lag))
+
+	if err := svc.PutData(ctx, "key", "value"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	if _, err := svc.GetData(ctx, "key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound immediately after the write, got %v", err)
+	}
+	if keys, err := svc.ListKeys(ctx); err != nil || len(keys) != 0 {
+		t.Errorf("Expected no keys listed before replication, got %v (%v)", keys, err)
+	}
+
+	time.Sleep(lag)
+	got, err := svc.GetData(ctx, "key")
+	if err != nil {
+		t.Fatalf("Expected the value after the lag, got %v", err)
+	}
+	if got != "value" {
+		t.Errorf("Expected %q, got %q", "value", got)
+	}
+	if keys, err := svc.ListKeys(ctx); err != nil || len(keys) != 1 {
+		t.Errorf("Expected the key to be listed after replication, got %v (%v)", keys, err)
+	}
+}
+
+func TestMockServiceCorruptionRate(t *testing.T) {
+	ctx := context.Background()
+	const value = "checking file integrity"
+
+	t.Run("always corrupt", func(t *testing.T) {
+		svc := NewMockService("Rotting", WithCorruptionRate(1.0), WithSeed(1))
+		if err := svc.PutData(ctx, "file", value); err != nil {
+			t.Fatalf("PutData failed: %v", err)
+		}
+		for i := 0; i < 10; i++ {
+			got, err := svc.GetData(ctx, "file")
+			if err != nil {
+				t.Fatalf("GetData failed: %v", err)
+			}
+			if got == value {
+				t.Fatalf("Expected a corrupted value, got the original")
+			}
+			if len(got) != len(value) {
+				t.Errorf("Expected corruption to keep the length, got %q", got)
+			}
+		}
+
+		// The stored value is untouched.
+		svc.CorruptionRate = 0
+		if got, _ := svc.GetData(ctx, "file"); got != value {
+			t.Errorf("Expected the stored value to be intact, got %q", got)
+		}
+	})
+
+	t.Run("never corrupt", func(t *testing.T) {
+		svc := NewMockService("Healthy", WithCorruptionRate(0))
+		if err := svc.PutData(ctx, "file", value); err != nil {
+			t.Fatalf("PutData failed: %v", err)
+		}
+		for i := 0; i < 10; i++ {
+			if got, err := svc.GetData(ctx, "file"); err != nil || got != value {
+				t.Fatalf("Expected %q, got %q (%v)", value, got, err)
+			}
+		}
+	})
+
+	t.Run("empty value", func(t *testing.T) {
+		svc := NewMockService("Rotting", WithCorruptionRate(1.0))
+		if err := svc.PutData(ctx, "empty", ""); err != nil {
+			t.Fatalf("PutData failed: %v", err)
+		}
+		if got, _ := svc.GetData(ctx, "empty"); got == "" {
+			t.Error("Expected an empty value to be corrupted too")
+		}
+	})
+}
+
+func TestMockServiceBandwidth(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(clockEpoch)
+	svc := NewMockService("Uplink",
+		WithClock(clock),
+		WithResponseTime(10*time.Millisecond),
+		WithBandwidth(1000),
+	)
+	var last time.Duration
+	svc.OnOperation = func(_, _ string, d time.Duration, _ error) { last = d }
+
+	// run performs op while advancing the fake clock until it completes,
+	// and returns the simulated time it took.
+	run := func(op func() error) time.Duration {
+		t.Helper()
+		done := make(chan error, 1)
+		go func() { done <- op() }()
+		for {
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatalf("Operation failed: %v", err)
+				}
+				return last
+			default:
+			}
+			if clock.Waiters() > 0 {
+				clock.Advance(time.Millisecond)
+			} else {
+				time.Sleep(100 * time.Microsecond)
+			}
+		}
+	}
+
+	tiny := run(func() error { return svc.PutData(ctx, "tiny", "x") })
+	large := run(func() error { return svc.PutData(ctx, "large", strings.Repeat("x", 2000)) })
+	download := run(func() error {
+		_, err := svc.GetData(ctx, "large")
+		return err
+	})
+
+	if tiny < 10*time.Millisecond || tiny > 12*time.Millisecond {
+		t.Errorf("Expected a tiny upload to take about the 10ms base latency, took %v", tiny)
+	}
+	if want := 10*time.Millisecond + 2*time.Second; large < want || large > want+2*time.Millisecond {
+		t.Errorf("Expected a 2000 byte upload at 1000 B/s to take about %v, took %v", want, large)
+	}
+	if want := 10*time.Millisecond + 2*time.Second; download < want || download > want+2*time.Millisecond {
+		t.Errorf("Expected a 2000 byte download at 1000 B/s to take about %v, took %v", want, download)
+	}
+}
+
+func TestMockServiceHooks(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Hooked", WithResponseTime(time.Millisecond))
+
+	type call struct {
+		when, op, key string
+		err           error
+		dur           time.Duration
+	}
+	var calls []call
+	svc.OnBefore(func(op, key string) {
+		calls = append(calls, call{when: "before", op: op, key: key})
+	})
+	svc.OnAfter(func(op, key string, err error, dur time.Duration) {
+		calls = append(calls, call{when: "after", op: op, key: key, err: err, dur: dur})
+	})
+
+	if err := svc.PutData(ctx, "present", "value"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	svc.failureRate = 1.0
+	getErr := second(svc.GetData(ctx, "present"))
+	if getErr == nil {
+		t.Fatal("Expected GetData to fail")
+	}
+
+	if len(calls) != 4 {
+		t.Fatalf("Expected 4 hook calls, got %d: %+v", len(calls), calls)
+	}
+	want := []struct{ when, op string }{
+		{"before", "PutData"}, {"after", "PutData"}, {"before", "GetData"}, {"after", "GetData"},
+	}
+	for i, w := range want {
+		if calls[i].when != w.when || calls[i].op != w.op || calls[i].key != "present" {
+			t.Errorf("Call %d: expected %s %s(present), got %+v", i, w.when, w.op, calls[i])
+		}
+	}
+	if calls[1].err != nil || calls[1].dur < time.Millisecond {
+		t.Errorf("Expected a successful PutData taking at least 1ms, got %+v", calls[1])
+	}
+	if calls[3].err != getErr {
+		t.Errorf("Expected the after hook to see the GetData error %v, got %v", getErr, calls[3].err)
+	}
+}
+
+func TestMockServiceStrictConnect(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("DB", WithStrictConnect(true))
+
+	if _, err := svc.GetData(ctx, "key"); !errors.Is(err, ErrNotConnected) {
+		t.Fatalf("Expected ErrNotConnected before Connect, got %v", err)
+	}
+	if err := svc.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.PutData(ctx, "key", "value"); err != nil {
+		t.Fatalf("Expected Put to succeed after Connect, got %v", err)
+	}
+	if got, err := svc.GetData(ctx, "key"); err != nil || got != "value" {
+		t.Fatalf("Expected Get to succeed after Connect, got %q, %v", got, err)
+	}
+
+	if err := svc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.ListKeys(ctx); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("Expected ErrNotConnected after Close, got %v", err)
+	}
+
+	// The lenient default does not require Connect.
+	if err := NewMockService("API").Ping(ctx); err != nil {
+		t.Errorf("Expected a lenient service to work without Connect, got %v", err)
+	}
+}
+
+func TestConnectEachJitter(t *testing.T) {
+	const services = 5
+	connect := func(jitter time.Duration) (spread, total time.Duration) {
+		var mu sync.Mutex
+		var stamps []time.Time
+		svcs := make([]ExternalService, services)
+		configs := make([]ServiceConfig, services)
+		for i := range svcs {
+			configs[i] = ServiceConfig{Name: fmt.Sprintf("svc%d", i), InitJitter: jitter}
+			svcs[i] = &trackingService{ExternalService: NewMockService(configs[i].Name), enter: func() {
+				mu.Lock()
+				defer mu.Unlock()
+				stamps = append(stamps, time.Now())
+			}, exit: func() {}}
+		}
+		start := time.Now()
+		if err := connectAll(context.Background(), svcs, configs, services); err != nil {
+			t.Fatal(err)
+		}
+		return stamps[services-1].Sub(stamps[0]), time.Since(start)
+	}
+
+	if spread, _ := connect(0); spread > 5*time.Millisecond {
+		t.Errorf("Expected no stagger without jitter, connects spread over %v", spread)
+	}
+	const jitter = 40 * time.Millisecond
+	spread, total := connect(jitter)
+	if spread < 5*time.Millisecond {
+		t.Errorf("Expected connects to spread out by up to %v, got %v", jitter, spread)
+	}
+	if total > jitter+50*time.Millisecond {
+		t.Errorf("Expected the jitter delays to overlap, connecting took %v", total)
+	}
+}
+
+func TestConnectEachJitterCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	svcs := []ExternalService{NewMockService("svc")}
+	configs := []ServiceConfig{{Name: "svc", InitJitter: time.Hour}}
+	if errs := connectEach(ctx, svcs, configs, 1); !errors.Is(errs[0], context.Canceled) {
+		t.Errorf("Expected a canceled context to stop the connect, got %v", errs[0])
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"time"
+)
+
+// Metadata describes a stored value, as object stores report alongside
+// the data.
+type Metadata struct {
+	// Size is the length of the value in bytes.
+	Size int
+	// CreatedAt is when the key was first written and ModifiedAt when it
+	// was last written.
+	CreatedAt  time.Time
+	ModifiedAt time.Time
+	// Version counts the writes to the key, starting at 1.
+	Version int
+	// ETag is the hex-encoded MD5 digest of the value.
+	ETag string
+	// CRC32 is the IEEE CRC-32 checksum of the value.
+	CRC32 uint32
+}
+
+// GetDataWithMetadata retrieves data from the mock service along with its
+// metadata. Values written to a shared Store by another service report
+// only their size, ETag and CRC32.
+func (m *MockService) GetDataWithMetadata(ctx context.Context, key string) (_ string, _ Metadata, err error) {
+	defer m.observe(ctx, m.startOp("GetDataWithMetadata", key), &err)
+	release, err := m.begin(ctx, m.warmupLatency(m.responseTime))
+	if err != nil {
+		return "", Metadata{}, err
+	}
+	defer release()
+	if m.shouldFail() {
+		return "", Metadata{}, fmt.Errorf("failed to get data from %s: %w", m.name, ErrTransient)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	val, err := m.load(key)
+	if err != nil {
+		return "", Metadata{}, err
+	}
+	md, ok := m.meta[m.storageKey(key)]
+	if !ok || md.ETag != etag(val) {
+		md = Metadata{Size: len(val), ETag: etag(val), CRC32: crc32.ChecksumIEEE([]byte(val))}
+	}
+	return val, md, nil
+}
+
+// touch updates the metadata of storage key k after value was written to
+// it. Callers must hold m.mu.
+func (m *MockService) touch(k, value string) {
+	if m.meta == nil {
+		m.meta = make(map[string]Metadata)
+	}
+	now := m.now()
+	md, ok := m.meta[k]
+	if !ok {
+		md.CreatedAt = now
+	}
+	md.Size = len(value)
+	md.ModifiedAt = now
+	md.Version++
+	md.ETag = etag(value)
+	md.CRC32 = crc32.ChecksumIEEE([]byte(value))
+	m.meta[k] = md
+}
+
+// etag returns the ETag of value.
+func etag(value string) string {
+	sum := md5.Sum([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
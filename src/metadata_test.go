@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGetDataWithMetadata(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Objects")
+
+	before := time.Now()
+	if err := svc.PutData(ctx, "object", "first"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	val, md, err := svc.GetDataWithMetadata(ctx, "object")
+	if err != nil {
+		t.Fatalf("GetDataWithMetadata failed: %v", err)
+	}
+	if val != "first" {
+		t.Errorf("Expected value %q, got %q", "first", val)
+	}
+	if md.Size != len("first") || md.Version != 1 || md.ETag != etag("first") {
+		t.Errorf("Unexpected metadata: %+v", md)
+	}
+	if md.CreatedAt.Before(before) || !md.ModifiedAt.Equal(md.CreatedAt) {
+		t.Errorf("Expected fresh, equal timestamps, got %+v", md)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	if err := svc.PutData(ctx, "object", "second value"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	_, updated, err := svc.GetDataWithMetadata(ctx, "object")
+	if err != nil {
+		t.Fatalf("GetDataWithMetadata failed: %v", err)
+	}
+	if !updated.CreatedAt.Equal(md.CreatedAt) {
+		t.Errorf("Expected CreatedAt to stay %v, got %v", md.CreatedAt, updated.CreatedAt)
+	}
+	if !updated.ModifiedAt.After(md.ModifiedAt) {
+		t.Errorf("Expected ModifiedAt to advance past %v, got %v", md.ModifiedAt, updated.ModifiedAt)
+	}
+	if updated.Size != len("second value") || updated.Version != 2 || updated.ETag == md.ETag {
+		t.Errorf("Unexpected metadata after overwrite: %+v", updated)
+	}
+}
+
+func TestGetDataWithMetadataAfterDelete(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Objects")
+
+	if err := svc.PutData(ctx, "object", "value"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	if err := svc.DeleteData(ctx, "object"); err != nil {
+		t.Fatalf("DeleteData failed: %v", err)
+	}
+	if _, _, err := svc.GetDataWithMetadata(ctx, "object"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+
+	if err := svc.PutData(ctx, "object", "again"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	if _, md, err := svc.GetDataWithMetadata(ctx, "object"); err != nil || md.Version != 1 {
+		t.Errorf("Expected a recreated key to start at version 1, got %+v (%v)", md, err)
+	}
+}
+
+func TestGetDataWithMetadataSharedStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	writer := NewMockService("Writer", WithStore(store))
+	reader := NewMockService("Reader", WithStore(store))
+
+	if err := writer.PutData(ctx, "object", "shared"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	_, md, err := reader.GetDataWithMetadata(ctx, "object")
+	if err != nil {
+		t.Fatalf("GetDataWithMetadata failed: %v", err)
+	}
+	if md.Size != len("shared") || md.ETag != etag("shared") || !md.CreatedAt.IsZero() {
+		t.Errorf("Expected size and ETag only, got %+v", md)
+	}
+}
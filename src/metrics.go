@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OpMetrics summarizes the completed calls of one operation.
+type OpMetrics struct {
+	Count    int
+	Failures int
+	// Latency is the total time spent in the operation across all calls.
+	Latency time.Duration
+}
+
+// Name returns the name the service was created with.
+func (m *MockService) Name() string {
+	return m.name
+}
+
+// Metrics returns a snapshot of the service's metrics keyed by operation
+// name.
+func (m *MockService) Metrics() map[string]OpMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]OpMetrics, len(m.metrics))
+	for op, om := range m.metrics {
+		out[op] = om
+	}
+	return out
+}
+
+// ResetMetrics discards all collected metrics, for example after a warmup
+// phase, so that later snapshots cover only subsequent calls.
+func (m *MockService) ResetMetrics() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.metrics = nil
+}
+
+// countOp adds a completed call of op to the service's metrics.
+func (m *MockService) countOp(op string, dur time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.metrics == nil {
+		m.metrics = make(map[string]OpMetrics)
+	}
+	om := m.metrics[op]
+	om.Count++
+	if err != nil {
+		om.Failures++
+	}
+	om.Latency += dur
+	m.metrics[op] = om
+}
+
+// MetricsSource is a service whose metrics MetricsHandler can expose.
+// MockService and the services built on it implement it.
+type MetricsSource interface {
+	Name() string
+	Metrics() map[string]OpMetrics
+}
+
+// MetricsHandler serves the metrics of its registered services in the
+// Prometheus text exposition format.
+type MetricsHandler struct {
+	mu      sync.Mutex
+	sources []MetricsSource
+}
+
+// NewMetricsHandler returns a MetricsHandler exposing sources.
+func NewMetricsHandler(sources ...MetricsSource) *MetricsHandler {
+	return &MetricsHandler{sources: sources}
+}
+
+// Register adds src to the services the handler exposes.
+func (h *MetricsHandler) Register(src MetricsSource) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sources = append(h.sources, src)
+}
+
+func (h *MetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	sources := append([]MetricsSource(nil), h.sources...)
+	h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	writeMetrics(w, sources)
+}
+
+// writeMetrics renders the metrics of sources in the Prometheus text
+// exposition format, ordered by service then operation.
+func writeMetrics(w io.Writer, sources []MetricsSource) {
+	type sample struct {
+		service, op string
+		metrics     OpMetrics
+	}
+	var samples []sample
+	for _, src := range sources {
+		snapshot := src.Metrics()
+		ops := make([]string, 0, len(snapshot))
+		for op := range snapshot {
+			ops = append(ops, op)
+		}
+		sort.Strings(ops)
+		for _, op := range ops {
+			samples = append(samples, sample{src.Name(), op, snapshot[op]})
+		}
+	}
+
+	families := []struct {
+		name, help string
+		value      func(OpMetrics) int
+	}{
+		{"operations_total", "Total number of operations performed.", func(om OpMetrics) int { return om.Count }},
+		{"failures_total", "Total number of operations that returned an error.", func(om OpMetrics) int { return om.Failures }},
+	}
+	for _, f := range families {
+		fmt.Fprintf(w, "# HELP %s %s\n", f.name, f.help)
+		fmt.Fprintf(w, "# TYPE %s counter\n", f.name)
+		for _, s := range samples {
+			fmt.Fprintf(w, "%s{service=\"%s\",operation=\"%s\"} %d\n",
+				f.name, labelEscaper.Replace(s.service), labelEscaper.Replace(s.op), f.value(s.metrics))
+		}
+	}
+}
+
+// labelEscaper escapes label values as the exposition format requires.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
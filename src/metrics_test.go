@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMockServiceMetrics(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Metered")
+
+	if err := svc.PutData(ctx, "key", "value"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	if _, err := svc.GetData(ctx, "key"); err != nil {
+		t.Fatalf("GetData failed: %v", err)
+	}
+	if _, err := svc.GetData(ctx, "missing"); err == nil {
+		t.Fatal("Expected GetData of a missing key to fail")
+	}
+
+	metrics := svc.Metrics()
+	if got := metrics["GetData"]; got.Count != 2 || got.Failures != 1 {
+		t.Errorf("Expected 2 GetData calls with 1 failure, got %+v", got)
+	}
+	if got := metrics["PutData"]; got.Count != 1 || got.Failures != 0 {
+		t.Errorf("Expected 1 successful PutData call, got %+v", got)
+	}
+	if _, ok := metrics["Ping"]; ok {
+		t.Error("Expected no metrics for operations that were never called")
+	}
+}
+
+func TestResetMetrics(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Metered", WithDryRun(true))
+
+	for i := 0; i < 3; i++ {
+		if err := svc.Ping(ctx); err != nil {
+			t.Fatalf("Ping failed: %v", err)
+		}
+	}
+	if err := svc.PutData(ctx, "key", "value"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+
+	svc.ResetMetrics()
+	if got := svc.Metrics(); len(got) != 0 {
+		t.Fatalf("Expected no metrics right after a reset, got %+v", got)
+	}
+
+	if err := svc.Ping(ctx); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	metrics := svc.Metrics()
+	if got := metrics["Ping"]; got.Count != 1 || got.Failures != 0 {
+		t.Errorf("Expected only the post-reset Ping, got %+v", got)
+	}
+	if _, ok := metrics["PutData"]; ok {
+		t.Error("Expected pre-reset PutData metrics to be discarded")
+	}
+}
+
+func TestResetMetricsConcurrent(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Metered", WithDryRun(true))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = svc.Ping(ctx)
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		svc.ResetMetrics()
+	}
+	wg.Wait()
+
+	if got := svc.Metrics()["Ping"]; got.Count > 400 {
+		t.Errorf("Expected at most 400 Pings after resets, got %d", got.Count)
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMockService("Storage")
+	api := NewMockService("API", WithFailureRate(1.0))
+
+	if err := storage.Ping(ctx); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if err := storage.Ping(ctx); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	_ = api.Connect(ctx)
+
+	handler := NewMetricsHandler(storage)
+	handler.Register(api)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Expected a text/plain content type, got %q", ct)
+	}
+
+	body := rec.Body.String()
+	for _, line := range []string{
+		"# HELP operations_total Total number of operations performed.",
+		"# TYPE operations_total counter",
+		`operations_total{service="Storage",operation="Ping"} 2`,
+		`operations_total{service="API",operation="Connect"} 1`,
+		"# TYPE failures_total counter",
+		`failures_total{service="Storage",operation="Ping"} 0`,
+		`failures_total{service="API",operation="Connect"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Expected exposition to contain %q, got:\n%s", line, body)
+		}
+	}
+}
+
+func TestMetricsLabelEscaping(t *testing.T) {
+	svc := NewMockService(`Quoted "svc"\n`)
+	_ = svc.Ping(context.Background())
+
+	var b strings.Builder
+	writeMetrics(&b, []MetricsSource{svc})
+	if want := `operations_total{service="Quoted \"svc\"\\n",operation="Ping"} 1`; !strings.Contains(b.String(), want) {
+		t.Errorf("Expected escaped label %q, got:\n%s", want, b.String())
+	}
+}
+
+func TestRunRegistersMetrics(t *testing.T) {
+	metrics := NewMetricsHandler()
+	configs := []ServiceConfig{{Name: "Storage", Type: TypeStorage, DryRun: true}}
+	if code := run(context.Background(), io.Discard, configs, metrics); code != exitOK {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if want := `operations_total{service="Storage",operation="Connect"} 1`; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("Expected run to register its services, got:\n%s", rec.Body.String())
+	}
+}
+
+func TestRunRegistersHTTPMetrics(t *testing.T) {
+	metrics := NewMetricsHandler()
+	configs := []ServiceConfig{{Name: "API", Type: TypeHTTP, DryRun: true}}
+	if code := run(context.Background(), io.Discard, configs, metrics); code != exitOK {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if want := `operations_total{service="API",operation="Connect"} 1`; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("Expected the HTTP service's backend metrics, got:\n%s", rec.Body.String())
+	}
+}
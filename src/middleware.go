@@ -0,0 +1,558 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Decorator wraps an ExternalService to add behavior such as retries,
+// timeouts or logging.
+type Decorator func(ExternalService) ExternalService
+
+// Chain wraps base with decorators. The first decorator is the outermost,
+// so calls pass through the decorators in the order given before reaching
+// base.
+func Chain(base ExternalService, decorators ...Decorator) ExternalService {
+	svc := base
+	for i := len(decorators) - 1; i >= 0; i-- {
+		svc = decorators[i](svc)
+	}
+	return svc
+}
+
+// RetryService retries operations that fail with a retryable error.
+type RetryService struct {
+	next     ExternalService
+	attempts int
+	backoff  time.Duration
+}
+
+// WithRetry retries each operation up to attempts times in total while it
+// fails with a retryable error, waiting backoff before the first retry and
+// doubling the wait after each one.
+func WithRetry(attempts int, backoff time.Duration) Decorator {
+	return func(next ExternalService) ExternalService {
+		return &RetryService{next: next, attempts: attempts, backoff: backoff}
+	}
+}
+
+func (s *RetryService) Connect(ctx context.Context) error {
+	return s.do(ctx, func() error { return s.next.Connect(ctx) })
+}
+
+func (s *RetryService) Ping(ctx context.Context) error {
+	return s.do(ctx, func() error { return s.next.Ping(ctx) })
+}
+
+func (s *RetryService) GetData(ctx context.Context, key string) (value string, err error) {
+	err = s.do(ctx, func() error {
+		value, err = s.next.GetData(ctx, key)
+		return err
+	})
+	return value, err
+}
+
+func (s *RetryService) PutData(ctx context.Context, key string, value string) error {
+	return s.do(ctx, func() error { return s.next.PutData(ctx, key, value) })
+}
+
+func (s *RetryService) DeleteData(ctx context.Context, key string) error {
+	return s.do(ctx, func() error { return s.next.DeleteData(ctx, key) })
+}
+
+func (s *RetryService) ListKeys(ctx context.Context) (keys []string, err error) {
+	err = s.do(ctx, func() error {
+		keys, err = s.next.ListKeys(ctx)
+		return err
+	})
+	return keys, err
+}
+
+// do calls fn until it succeeds, fails permanently, runs out of attempts or
+// ctx is done.
+func (s *RetryService) do(ctx context.Context, fn func() error) error {
+	wait := s.backoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !IsRetryable(err) || attempt >= s.attempts {
+			return err
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+		wait *= 2
+	}
+}
+
+// TimeoutService bounds how long each operation may take.
+type TimeoutService struct {
+	next     ExternalService
+	timeout  time.Duration
+	timeouts map[string]time.Duration
+}
+
+// WithTimeout cancels the context of each operation after timeout.
+func WithTimeout(timeout time.Duration) Decorator {
+	return func(next ExternalService) ExternalService {
+		return &TimeoutService{next: next, timeout: timeout}
+	}
+}
+
+// WithMethodTimeouts gives each operation its own timeout, keyed by
+// operation name, as SDK clients are usually configured:
+//
+//	timeouts, err := WithMethodTimeouts(map[string]time.Duration{
+//		"GetData":  time.Second,
+//		"PutData":  2 * time.Second,
+//		"ListKeys": 5 * time.Second,
+//	}, time.Second)
+//
+// Operations missing from timeouts, or given a zero timeout, use fallback.
+// A name that is not an ExternalService method, or a negative timeout, is
+// an error.
+func WithMethodTimeouts(timeouts map[string]time.Duration, fallback time.Duration) (Decorator, error) {
+	copied := make(map[string]time.Duration, len(timeouts))
+	for op, timeout := range timeouts {
+		if _, ok := serviceMethods.MethodByName(op); !ok {
+			return nil, fmt.Errorf("unknown operation %q in method timeouts", op)
+		}
+		if timeout < 0 {
+			return nil, fmt.Errorf("negative timeout %v for %s in method timeouts", timeout, op)
+		}
+		if timeout > 0 {
+			copied[op] = timeout
+		}
+	}
+	return func(next ExternalService) ExternalService {
+		return &TimeoutService{next: next, timeout: fallback, timeouts: copied}
+	}, nil
+}
+
+// serviceMethods is the ExternalService interface type, whose methods name
+// the operations a TimeoutService can time out.
+var serviceMethods = reflect.TypeOf((*ExternalService)(nil)).Elem()
+
+// withTimeout derives the context for op from ctx.
+func (s *TimeoutService) withTimeout(ctx context.Context, op string) (context.Context, context.CancelFunc) {
+	timeout, ok := s.timeouts[op]
+	if !ok {
+		timeout = s.timeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func (s *TimeoutService) Connect(ctx context.Context) error {
+	ctx, cancel := s.withTimeout(ctx, "Connect")
+	defer cancel()
+	return s.next.Connect(ctx)
+}
+
+func (s *TimeoutService) Ping(ctx context.Context) error {
+	ctx, cancel := s.withTimeout(ctx, "Ping")
+	defer cancel()
+	return s.next.Ping(ctx)
+}
+
+func (s *TimeoutService) GetData(ctx context.Context, key string) (string, error) {
+	ctx, cancel := s.withTimeout(ctx, "GetData")
+	defer cancel()
+	return s.next.GetData(ctx, key)
+}
+
+func (s *TimeoutService) PutData(ctx context.Context, key string, value string) error {
+	ctx, cancel := s.withTimeout(ctx, "PutData")
+	defer cancel()
+	return s.next.PutData(ctx, key, value)
+}
+
+func (s *TimeoutService) DeleteData(ctx context.Context, key string) error {
+	ctx, cancel := s.withTimeout(ctx, "DeleteData")
+	defer cancel()
+	return s.next.DeleteData(ctx, key)
+}
+
+func (s *TimeoutService) ListKeys(ctx context.Context) ([]string, error) {
+	ctx, cancel := s.withTimeout(ctx, "ListKeys")
+	defer cancel()
+	return s.next.ListKeys(ctx)
+}
+
+// LoggingService logs every call made through it.
+type LoggingService struct {
+	next   ExternalService
+	logger *slog.Logger
+}
+
+// WithLogging logs each operation to logger as an info record with the
+// operation, key, duration and any error as attributes.
+func WithLogging(logger *slog.Logger) Decorator {
+	return func(next ExternalService) ExternalService {
+		return &LoggingService{next: next, logger: logger}
+	}
+}
+
+func (s *LoggingService) Connect(ctx context.Context) (err error) {
+	defer s.log(ctx, "Connect", "", time.Now(), &err)
+	return s.next.Connect(ctx)
+}
+
+func (s *LoggingService) Ping(ctx context.Context) (err error) {
+	defer s.log(ctx, "Ping", "", time.Now(), &err)
+	return s.next.Ping(ctx)
+}
+
+func (s *LoggingService) GetData(ctx context.Context, key string) (_ string, err error) {
+	defer s.log(ctx, "GetData", key, time.Now(), &err)
+	return s.next.GetData(ctx, key)
+}
+
+func (s *LoggingService) PutData(ctx context.Context, key string, value string) (err error) {
+	defer s.log(ctx, "PutData", key, time.Now(), &err)
+	return s.next.PutData(ctx, key, value)
+}
+
+func (s *LoggingService) DeleteData(ctx context.Context, key string) (err error) {
+	defer s.log(ctx, "DeleteData", key, time.Now(), &err)
+	return s.next.DeleteData(ctx, key)
+}
+
+func (s *LoggingService) ListKeys(ctx context.Context) (_ []string, err error) {
+	defer s.log(ctx, "ListKeys", "", time.Now(), &err)
+	return s.next.ListKeys(ctx)
+}
+
+func (s *LoggingService) log(ctx context.Context, op, key string, start time.Time, err *error) {
+	attrs := []slog.Attr{slog.String("op", op)}
+	if key != "" {
+		attrs = append(attrs, slog.String("key", key))
+	}
+	attrs = append(attrs, slog.Duration("duration", time.Since(start)))
+	if *err != nil {
+		attrs = append(attrs, slog.Any("error", *err))
+	}
+	s.logger.LogAttrs(ctx, slog.LevelInfo, "call", attrs...)
+}
+
+// TokenRefreshService attaches an access token to every call and, when a
+// call fails with ErrUnauthorized, fetches a new token and retries the call
+// once, as SDKs do when a session expires.
+type TokenRefreshService struct {
+	next    ExternalService
+	refresh func(ctx context.Context) (string, error)
+
+	mu    sync.Mutex
+	token string
+}
+
+// WithTokenRefresh calls refresh for a new token whenever an operation
+// fails with ErrUnauthorized and retries the operation once with it. The
+// token is kept for later calls, which carry it in their context (see
+// ContextWithToken).
+func WithTokenRefresh(refresh func(ctx context.Context) (string, error)) Decorator {
+	return func(next ExternalService) ExternalService {
+		return &TokenRefreshService{next: next, refresh: refresh}
+	}
+}
+
+func (s *TokenRefreshService) Connect(ctx context.Context) error {
+	return s.do(ctx, func(ctx context.Context) error { return s.next.Connect(ctx) })
+}
+
+func (s *TokenRefreshService) Ping(ctx context.Context) error {
+	return s.do(ctx, func(ctx context.Context) error { return s.next.Ping(ctx) })
+}
+
+func (s *TokenRefreshService) GetData(ctx context.Context, key string) (value string, err error) {
+	err = s.do(ctx, func(ctx context.Context) error {
+		value, err = s.next.GetData(ctx, key)
+		return err
+	})
+	return value, err
+}
+
+func (s *TokenRefreshService) PutData(ctx context.Context, key string, value string) error {
+	return s.do(ctx, func(ctx context.Context) error { return s.next.PutData(ctx, key, value) })
+}
+
+func (s *TokenRefreshService) DeleteData(ctx context.Context, key string) error {
+	return s.do(ctx, func(ctx context.Context) error { return s.next.DeleteData(ctx, key) })
+}
+
+func (s *TokenRefreshService) ListKeys(ctx context.Context) (keys []string, err error) {
+	err = s.do(ctx, func(ctx context.Context) error {
+		keys, err = s.next.ListKeys(ctx)
+		return err
+	})
+	return keys, err
+}
+
+// do calls fn with the current token and, if it is rejected, once more
+// with a refreshed one.
+func (s *TokenRefreshService) do(ctx context.Context, fn func(context.Context) error) error {
+	s.mu.Lock()
+	token := s.token
+	s.mu.Unlock()
+
+	err := fn(withToken(ctx, token))
+	if !errors.Is(err, ErrUnauthorized) {
+		return err
+	}
+	token, refreshErr := s.refresh(ctx)
+	if refreshErr != nil {
+		return fmt.Errorf("token refresh failed: %w", errors.Join(refreshErr, err))
+	}
+	s.mu.Lock()
+	s.token = token
+	s.mu.Unlock()
+	return fn(withToken(ctx, token))
+}
+
+// withToken attaches token to ctx unless it is empty.
+func withToken(ctx context.Context, token string) context.Context {
+	if token == "" {
+		return ctx
+	}
+	return ContextWithToken(ctx, token)
+}
+
+// ReconnectService tracks whether the wrapped service is connected and,
+// when an operation fails because the connection was lost, reconnects
+// once and retries the operation once, as database drivers do.
+type ReconnectService struct {
+	next ExternalService
+
+	mu         sync.Mutex
+	connected  bool
+	reconnects int
+}
+
+// WithAutoReconnect reconnects the service with a single Connect call when
+// an operation fails with ErrConnectionLost, then retries the operation
+// once.
+func WithAutoReconnect() Decorator {
+	return func(next ExternalService) ExternalService {
+		return &ReconnectService{next: next}
+	}
+}
+
+// Connected reports whether the last Connect succeeded and no operation
+// has lost the connection since.
+func (s *ReconnectService) Connected() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connected
+}
+
+// Reconnects returns how many times the service has reconnected after a
+// lost connection.
+func (s *ReconnectService) Reconnects() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reconnects
+}
+
+func (s *ReconnectService) Connect(ctx context.Context) error {
+	err := s.next.Connect(ctx)
+	s.mu.Lock()
+	s.connected = err == nil
+	s.mu.Unlock()
+	return err
+}
+
+func (s *ReconnectService) Ping(ctx context.Context) error {
+	return s.do(ctx, func() error { return s.next.Ping(ctx) })
+}
+
+func (s *ReconnectService) GetData(ctx context.Context, key string) (value string, err error) {
+	err = s.do(ctx, func() error {
+		value, err = s.next.GetData(ctx, key)
+		return err
+	})
+	return value, err
+}
+
+func (s *ReconnectService) PutData(ctx context.Context, key string, value string) error {
+	return s.do(ctx, func() error { return s.next.PutData(ctx, key, value) })
+}
+
+func (s *ReconnectService) DeleteData(ctx context.Context, key string) error {
+	return s.do(ctx, func() error { return s.next.DeleteData(ctx, key) })
+}
+
+func (s *ReconnectService) ListKeys(ctx context.Context) (keys []string, err error) {
+	err = s.do(ctx, func() error {
+		keys, err = s.next.ListKeys(ctx)
+		return err
+	})
+	return keys, err
+}
+
+// do calls fn and, if it lost the connection, reconnects and calls it
+// once more.
+func (s *ReconnectService) do(ctx context.Context, fn func() error) error {
+	err := fn()
+	if !errors.Is(err, ErrConnectionLost) {
+		return err
+	}
+	s.mu.Lock()
+	s.connected = false
+	s.mu.Unlock()
+	if connErr := s.Connect(ctx); connErr != nil {
+		return fmt.Errorf("reconnect failed: %w", errors.Join(connErr, err))
+	}
+	s.mu.Lock()
+	s.reconnects++
+	s.mu.Unlock()
+	return fn()
+}
+
+// CachingService memoizes GetData results for TTL. Cache hits are served
+// without calling the wrapped service, so they see no latency or
+// failures. PutData and DeleteData drop the cached entry for their key.
+// Expired entries are dropped when looked up, and swept whenever the cache
+// has doubled in size since the last sweep, so it stays bounded by the
+// number of keys read within a TTL.
+type CachingService struct {
+	next  ExternalService
+	ttl   time.Duration
+	clock Clock
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	// sweepAt is the size at which the next insert sweeps expired entries.
+	sweepAt int
+	// gen counts invalidations, so that a read racing with a write does
+	// not cache the value the write replaced.
+	gen    uint64
+	hits   int
+	misses int
+}
+
+type cacheEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+// minCacheSweep is the cache size below which expired entries are not
+// swept.
+const minCacheSweep = 64
+
+// WithCache caches successful GetData results for ttl, as measured by
+// clock. A nil clock uses the system clock.
+func WithCache(ttl time.Duration, clock Clock) Decorator {
+	if clock == nil {
+		clock = RealClock{}
+	}
+	return func(next ExternalService) ExternalService {
+		return &CachingService{
+			next:    next,
+			ttl:     ttl,
+			clock:   clock,
+			entries: make(map[string]cacheEntry),
+			sweepAt: minCacheSweep,
+		}
+	}
+}
+
+// Hits returns how many GetData calls were served from the cache.
+func (s *CachingService) Hits() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits
+}
+
+// Misses returns how many GetData calls went to the wrapped service.
+func (s *CachingService) Misses() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.misses
+}
+
+func (s *CachingService) Connect(ctx context.Context) error {
+	return s.next.Connect(ctx)
+}
+
+func (s *CachingService) Ping(ctx context.Context) error {
+	return s.next.Ping(ctx)
+}
+
+func (s *CachingService) GetData(ctx context.Context, key string) (string, error) {
+	s.mu.Lock()
+	if e, ok := s.entries[key]; ok {
+		if s.clock.Now().Before(e.expiresAt) {
+			s.hits++
+			s.mu.Unlock()
+			return e.value, nil
+		}
+		delete(s.entries, key)
+	}
+	s.misses++
+	gen := s.gen
+	s.mu.Unlock()
+
+	value, err := s.next.GetData(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	if gen == s.gen {
+		if len(s.entries) >= s.sweepAt {
+			s.sweep()
+		}
+		s.entries[key] = cacheEntry{value: value, expiresAt: s.clock.Now().Add(s.ttl)}
+	}
+	s.mu.Unlock()
+	return value, nil
+}
+
+// sweep drops every expired entry. Callers must hold s.mu.
+func (s *CachingService) sweep() {
+	now := s.clock.Now()
+	for key, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	s.sweepAt = max(2*len(s.entries), minCacheSweep)
+}
+
+// Len returns how many entries the cache holds, expired ones included.
+func (s *CachingService) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+func (s *CachingService) PutData(ctx context.Context, key string, value string) error {
+	err := s.next.PutData(ctx, key, value)
+	s.invalidate(key)
+	return err
+}
+
+func (s *CachingService) DeleteData(ctx context.Context, key string) error {
+	err := s.next.DeleteData(ctx, key)
+	s.invalidate(key)
+	return err
+}
+
+func (s *CachingService) ListKeys(ctx context.Context) ([]string, error) {
+	return s.next.ListKeys(ctx)
+}
+
+// invalidate drops key from the cache. It runs whatever the outcome of the
+// write, since a failed write may still have landed.
+func (s *CachingService) invalidate(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	s.gen++
+}
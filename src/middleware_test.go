@@ -0,0 +1,483 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flakyService fails the first failures GetData calls with a transient
+// error before delegating to the wrapped service.
+type flakyService struct {
+	ExternalService
+	failures int
+	calls    int
+}
+
+func (s *flakyService) GetData(ctx context.Context, key string) (string, error) {
+	s.calls++
+	if s.calls <= s.failures {
+		return "", fmt.Errorf("flaky: %w", ErrTransient)
+	}
+	return s.ExternalService.GetData(ctx, key)
+}
+
+func TestChainLoggingAndRetry(t *testing.T) {
+	ctx := context.Background()
+	base := NewMockService("Chained")
+	if err := base.PutData(ctx, "key", "value"); err != nil {
+		t.Fatalf("PutData: %v", err)
+	}
+	flaky := &flakyService{ExternalService: base, failures: 1}
+
+	var buf bytes.Buffer
+	svc := Chain(flaky,
+		WithRetry(3, time.Millisecond),
+		WithLogging(slog.New(slog.NewTextHandler(&buf, nil))),
+	)
+
+	got, err := svc.GetData(ctx, "key")
+	if err != nil {
+		t.Fatalf("Expected the retry to succeed, got %v", err)
+	}
+	if got != "value" {
+		t.Errorf("Expected %q, got %q", "value", got)
+	}
+	if flaky.calls != 2 {
+		t.Errorf("Expected 2 calls, got %d", flaky.calls)
+	}
+
+	// Logging sits inside the retry, so it sees every attempt.
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, got %d: %q", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], "op=GetData key=key") || !strings.Contains(lines[0], "error=") {
+		t.Errorf("Expected the first attempt to be logged as failed, got %q", lines[0])
+	}
+	if strings.Contains(lines[1], "error=") {
+		t.Errorf("Expected the retry to be logged as successful, got %q", lines[1])
+	}
+}
+
+func TestRetryStopsOnPermanentError(t *testing.T) {
+	svc := Chain(NewMockService("Permanent"), WithRetry(3, time.Millisecond))
+
+	_, err := svc.GetData(context.Background(), "missing")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	flaky := &flakyService{ExternalService: NewMockService("Down"), failures: 5}
+	svc := Chain(flaky, WithRetry(3, time.Millisecond))
+
+	if _, err := svc.GetData(context.Background(), "key"); !errors.Is(err, ErrTransient) {
+		t.Errorf("Expected ErrTransient, got %v", err)
+	}
+	if flaky.calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", flaky.calls)
+	}
+}
+
+// transientFailures fails the first failures calls of each operation with
+// a transient error before delegating to the wrapped service, counting
+// calls by operation.
+type transientFailures struct {
+	ExternalService
+	failures int
+	calls    map[string]int
+}
+
+func (s *transientFailures) attempt(op string) error {
+	s.calls[op]++
+	if s.calls[op] <= s.failures {
+		return fmt.Errorf("%s dropped: %w", op, ErrTransient)
+	}
+	return nil
+}
+
+func (s *transientFailures) Connect(ctx context.Context) error {
+	if err := s.attempt("Connect"); err != nil {
+		return err
+	}
+	return s.ExternalService.Connect(ctx)
+}
+
+func (s *transientFailures) Ping(ctx context.Context) error {
+	if err := s.attempt("Ping"); err != nil {
+		return err
+	}
+	return s.ExternalService.Ping(ctx)
+}
+
+func (s *transientFailures) GetData(ctx context.Context, key string) (string, error) {
+	if err := s.attempt("GetData"); err != nil {
+		return "", err
+	}
+	return s.ExternalService.GetData(ctx, key)
+}
+
+func (s *transientFailures) PutData(ctx context.Context, key, value string) error {
+	if err := s.attempt("PutData"); err != nil {
+		return err
+	}
+	return s.ExternalService.PutData(ctx, key, value)
+}
+
+func (s *transientFailures) DeleteData(ctx context.Context, key string) error {
+	if err := s.attempt("DeleteData"); err != nil {
+		return err
+	}
+	return s.ExternalService.DeleteData(ctx, key)
+}
+
+func (s *transientFailures) ListKeys(ctx context.Context) ([]string, error) {
+	if err := s.attempt("ListKeys"); err != nil {
+		return nil, err
+	}
+	return s.ExternalService.ListKeys(ctx)
+}
+
+func TestRetryEveryOperation(t *testing.T) {
+	ctx := context.Background()
+	backend := &transientFailures{ExternalService: NewMockService("Flaky"), failures: 2, calls: map[string]int{}}
+	svc := Chain(backend, WithRetry(3, time.Millisecond))
+
+	if err := svc.Connect(ctx); err != nil {
+		t.Errorf("Connect: %v", err)
+	}
+	if err := svc.Ping(ctx); err != nil {
+		t.Errorf("Ping: %v", err)
+	}
+	if err := svc.PutData(ctx, "key", "value"); err != nil {
+		t.Errorf("PutData: %v", err)
+	}
+	if got, err := svc.GetData(ctx, "key"); err != nil || got != "value" {
+		t.Errorf("GetData = %q, %v", got, err)
+	}
+	if keys, err := svc.ListKeys(ctx); err != nil || len(keys) != 1 {
+		t.Errorf("ListKeys = %v, %v", keys, err)
+	}
+	if err := svc.DeleteData(ctx, "key"); err != nil {
+		t.Errorf("DeleteData: %v", err)
+	}
+	for _, op := range []string{"Connect", "Ping", "GetData", "PutData", "DeleteData", "ListKeys"} {
+		if backend.calls[op] != 3 {
+			t.Errorf("Expected %s to be attempted 3 times, got %d", op, backend.calls[op])
+		}
+	}
+
+	// A permanent error is returned after the first attempt that reaches
+	// the backend.
+	backend.calls = map[string]int{}
+	backend.failures = 0
+	if _, err := svc.GetData(ctx, "key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if backend.calls["GetData"] != 1 {
+		t.Errorf("Expected ErrNotFound not to be retried, got %d attempts", backend.calls["GetData"])
+	}
+}
+
+func TestRetryRespectsContext(t *testing.T) {
+	backend := &transientFailures{ExternalService: NewMockService("Down"), failures: 10, calls: map[string]int{}}
+	svc := Chain(backend, WithRetry(10, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := svc.PutData(ctx, "key", "value"); !errors.Is(err, ErrTransient) {
+		t.Errorf("Expected the last transient error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the backoff to end with the context, took %v", elapsed)
+	}
+	if backend.calls["PutData"] != 1 {
+		t.Errorf("Expected no retry after the context ended, got %d attempts", backend.calls["PutData"])
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	slow := NewMockService("Slow", WithResponseTime(time.Second))
+	svc := Chain(slow, WithTimeout(10*time.Millisecond))
+
+	start := time.Now()
+	err := svc.Ping(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected Ping to be cut short, took %v", elapsed)
+	}
+}
+
+func TestChainOrder(t *testing.T) {
+	var order []string
+	mark := func(name string) Decorator {
+		return func(next ExternalService) ExternalService {
+			order = append(order, name)
+			return next
+		}
+	}
+
+	Chain(NewMockService("Order"), mark("outer"), mark("inner"))
+	if got := strings.Join(order, ","); got != "inner,outer" {
+		t.Errorf("Expected decorators to wrap inner first, got %s", got)
+	}
+}
+
+func TestTokenRefresh(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(clockEpoch)
+	creds := Credentials{ClientID: "demo", Secret: "s3cret"}
+	auth := NewAuthService(NewMockService("API", WithDryRun(true)), creds, time.Minute)
+	auth.Clock = clock
+
+	refreshes := 0
+	svc := Chain(auth, WithTokenRefresh(func(ctx context.Context) (string, error) {
+		refreshes++
+		token, err := auth.Authenticate(ctx, creds)
+		return token.Value, err
+	}))
+
+	// The first call has no token, fails with ErrUnauthorized, and is
+	// retried with the refreshed token.
+	if err := svc.PutData(ctx, "key", "value"); err != nil {
+		t.Fatalf("Expected the retried call to succeed, got %v", err)
+	}
+	if got, err := svc.GetData(ctx, "key"); err != nil || got != "value" {
+		t.Fatalf("Expected the kept token to authorize the read, got %q, %v", got, err)
+	}
+	if refreshes != 1 {
+		t.Errorf("Expected 1 refresh, got %d", refreshes)
+	}
+
+	clock.Advance(time.Minute)
+	if err := svc.Ping(ctx); err != nil {
+		t.Errorf("Expected an expired token to be refreshed, got %v", err)
+	}
+	if refreshes != 2 {
+		t.Errorf("Expected 2 refreshes, got %d", refreshes)
+	}
+}
+
+func TestTokenRefreshFailure(t *testing.T) {
+	ctx := context.Background()
+	auth := NewAuthService(NewMockService("API"), Credentials{ClientID: "demo"}, time.Minute)
+	refreshErr := errors.New("identity provider down")
+	svc := Chain(auth, WithTokenRefresh(func(context.Context) (string, error) {
+		return "", refreshErr
+	}))
+
+	err := svc.Ping(ctx)
+	if !errors.Is(err, refreshErr) || !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected both the refresh and the original error, got %v", err)
+	}
+
+	// A refreshed token that is still rejected is not retried again.
+	calls := 0
+	svc = Chain(auth, WithTokenRefresh(func(context.Context) (string, error) {
+		calls++
+		return "forged", nil
+	}))
+	if err := svc.Ping(ctx); !errors.Is(err, ErrUnauthorized) || calls != 1 {
+		t.Errorf("Expected one refresh and ErrUnauthorized, got %d, %v", calls, err)
+	}
+}
+
+func TestAutoReconnect(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMockService("DB")
+	rs := Chain(backend, WithAutoReconnect()).(*ReconnectService)
+
+	if rs.Connected() {
+		t.Fatal("Expected a new service to be disconnected")
+	}
+	if err := rs.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !rs.Connected() {
+		t.Fatal("Expected the service to be connected after Connect")
+	}
+	if err := rs.PutData(ctx, "key", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The dropped connection fails the read, which reconnects and is
+	// retried.
+	backend.Disconnect()
+	if _, err := backend.GetData(ctx, "key"); !errors.Is(err, ErrConnectionLost) {
+		t.Fatalf("Expected ErrConnectionLost after Disconnect, got %v", err)
+	}
+	if got, err := rs.GetData(ctx, "key"); err != nil || got != "value" {
+		t.Fatalf("Expected the retried read to succeed, got %q, %v", got, err)
+	}
+	if got := backend.Metrics()["Connect"].Count; got != 2 || rs.Reconnects() != 1 {
+		t.Errorf("Expected 2 connects and 1 reconnect, got %d and %d", got, rs.Reconnects())
+	}
+	if !rs.Connected() {
+		t.Error("Expected the service to be connected after reconnecting")
+	}
+}
+
+func TestAutoReconnectFailure(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMockService("DB", WithFailureRate(1))
+	backend.Disconnect()
+	rs := Chain(backend, WithAutoReconnect()).(*ReconnectService)
+
+	_, err := rs.GetData(ctx, "key")
+	if !errors.Is(err, ErrConnectionLost) || !strings.Contains(err.Error(), "failed to connect") {
+		t.Errorf("Expected the lost connection and the failed Connect, got %v", err)
+	}
+	if rs.Connected() || rs.Reconnects() != 0 {
+		t.Errorf("Expected one failed reconnect, got connected %v, %d reconnects", rs.Connected(), rs.Reconnects())
+	}
+
+	// Errors other than a lost connection are returned as is.
+	rs = Chain(NewMockService("DB"), WithAutoReconnect()).(*ReconnectService)
+	if _, err := rs.GetData(ctx, "missing"); !errors.Is(err, ErrNotFound) || rs.Reconnects() != 0 {
+		t.Errorf("Expected ErrNotFound without a reconnect, got %v", err)
+	}
+}
+
+func TestWithMethodTimeouts(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMockService("API", WithResponseTime(50*time.Millisecond))
+	timeouts, err := WithMethodTimeouts(map[string]time.Duration{
+		"GetData":  time.Second,
+		"PutData":  10 * time.Millisecond,
+		"ListKeys": time.Second,
+		"Ping":     0,
+	}, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := Chain(backend, timeouts)
+
+	if err := svc.PutData(ctx, "key", "value"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the slow Put to trip the Put timeout, got %v", err)
+	}
+	if _, err := svc.GetData(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected Get to finish within its timeout, got %v", err)
+	}
+	if _, err := svc.ListKeys(ctx); err != nil {
+		t.Errorf("Expected List to finish within its timeout, got %v", err)
+	}
+	if err := svc.Ping(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Ping to use the fallback timeout, got %v", err)
+	}
+}
+
+func TestWithMethodTimeoutsInvalid(t *testing.T) {
+	for name, timeouts := range map[string]map[string]time.Duration{
+		"misspelled": {"GetDate": time.Second},
+		"negative":   {"GetData": -time.Second},
+	} {
+		if _, err := WithMethodTimeouts(timeouts, time.Second); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestWithCache(t *testing.T) {
+	ctx := context.Background()
+	const responseTime = 20 * time.Millisecond
+	clock := NewFakeClock(clockEpoch)
+	backend := NewMockService("API", WithResponseTime(responseTime))
+	cache := Chain(backend, WithCache(time.Minute, clock)).(*CachingService)
+
+	if err := cache.PutData(ctx, "key", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := cache.GetData(ctx, "key"); err != nil || got != "v1" {
+		t.Fatalf("Expected v1, got %q, %v", got, err)
+	}
+
+	// Within the TTL the cache answers, even with the backend down.
+	backend.Pause()
+	start := time.Now()
+	if got, err := cache.GetData(ctx, "key"); err != nil || got != "v1" {
+		t.Fatalf("Expected a cache hit, got %q, %v", got, err)
+	}
+	if elapsed := time.Since(start); elapsed >= responseTime {
+		t.Errorf("Expected a cache hit to skip the backend's latency, took %v", elapsed)
+	}
+	backend.Resume()
+	if cache.Hits() != 1 || cache.Misses() != 1 {
+		t.Errorf("Expected 1 hit and 1 miss, got %d and %d", cache.Hits(), cache.Misses())
+	}
+
+	// A write invalidates the entry.
+	if err := cache.PutData(ctx, "key", "v2"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := cache.GetData(ctx, "key"); err != nil || got != "v2" {
+		t.Errorf("Expected the write to invalidate the cache, got %q, %v", got, err)
+	}
+
+	// So does the TTL running out.
+	if err := backend.PutData(ctx, "key", "v3"); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	if got, err := cache.GetData(ctx, "key"); err != nil || got != "v3" {
+		t.Errorf("Expected the entry to expire, got %q, %v", got, err)
+	}
+	if cache.Hits() != 1 || cache.Misses() != 3 {
+		t.Errorf("Expected 1 hit and 3 misses, got %d and %d", cache.Hits(), cache.Misses())
+	}
+
+	if err := cache.DeleteData(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.GetData(ctx, "key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected a delete to invalidate the cache, got %v", err)
+	}
+}
+
+func TestWithCacheEvictsExpiredEntries(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(clockEpoch)
+	backend := NewMockService("API")
+	cache := Chain(backend, WithCache(time.Minute, clock)).(*CachingService)
+
+	if err := backend.PutData(ctx, "key", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.GetData(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	if err := backend.DeleteData(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.GetData(ctx, "key"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	if got := cache.Len(); got != 0 {
+		t.Errorf("Expected the expired entry to be dropped on lookup, got %d entries", got)
+	}
+
+	// Keys that are never read again are swept as the cache grows.
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if err := backend.PutData(ctx, key, "value"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := cache.GetData(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+		clock.Advance(time.Second)
+	}
+	if got := cache.Len(); got > 2*60+minCacheSweep {
+		t.Errorf("Expected expired entries to be swept, got %d entries", got)
+	}
+}
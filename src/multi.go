@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Replica is a named service behind a MultiService.
+type Replica struct {
+	Name    string
+	Service ExternalService
+}
+
+// MultiService is an ExternalService that replicates data across several
+// backends. Writes and deletes go to every replica and succeed once
+// WriteQuorum of them acknowledge; reads are served by the first replica
+// that answers, failing over to the next on a retryable error.
+//
+// Every replica call runs under a context derived from the caller's, so
+// a fan-out shares the caller's deadline instead of extending it.
+type MultiService struct {
+	// WriteQuorum is how many replicas must acknowledge a write or delete
+	// for it to succeed.
+	WriteQuorum int
+
+	// Clock, when set, replaces the system clock for partition windows.
+	Clock Clock
+
+	// MinCallTime is the least time that must remain before the caller's
+	// deadline for a replica call to be started. Calls that would start
+	// with less are skipped and fail with context.DeadlineExceeded. Zero
+	// only skips calls once the deadline has passed.
+	MinCallTime time.Duration
+
+	replicas []Replica
+
+	mu sync.Mutex
+	// partitioned holds when each partitioned replica becomes reachable
+	// again, by name.
+	partitioned map[string]time.Time
+}
+
+// NewMultiService creates a MultiService over replicas, which are read in
+// the order given. WriteQuorum defaults to a majority of the replicas.
+func NewMultiService(replicas ...Replica) *MultiService {
+	return &MultiService{
+		WriteQuorum: len(replicas)/2 + 1,
+		replicas:    append([]Replica(nil), replicas...),
+	}
+}
+
+// Partition cuts the named replicas off for duration, simulating a network
+// split: until the window elapses every call to them fails with
+// ErrUnavailable without reaching the backend. Partitioning a replica
+// that is already partitioned restarts its window.
+func (s *MultiService) Partition(names []string, duration time.Duration) error {
+	for _, name := range names {
+		if s.replica(name) == nil {
+			return fmt.Errorf("unknown replica %q", name)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.partitioned == nil {
+		s.partitioned = make(map[string]time.Time)
+	}
+	until := s.now().Add(duration)
+	for _, name := range names {
+		s.partitioned[name] = until
+	}
+	return nil
+}
+
+// Reachable reports whether the named replica is outside any partition.
+func (s *MultiService) Reachable(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	until, ok := s.partitioned[name]
+	if !ok {
+		return true
+	}
+	if s.now().Before(until) {
+		return false
+	}
+	delete(s.partitioned, name)
+	return true
+}
+
+// Connect connects every replica and succeeds if a quorum connected.
+func (s *MultiService) Connect(ctx context.Context) error {
+	return s.quorum(ctx, "connect", func(ctx context.Context, svc ExternalService) error { return svc.Connect(ctx) })
+}
+
+// Ping pings every replica and succeeds if a quorum answered.
+func (s *MultiService) Ping(ctx context.Context) error {
+	return s.quorum(ctx, "ping", func(ctx context.Context, svc ExternalService) error { return svc.Ping(ctx) })
+}
+
+func (s *MultiService) GetData(ctx context.Context, key string) (value string, err error) {
+	err = s.failover(ctx, func(ctx context.Context, svc ExternalService) error {
+		value, err = svc.GetData(ctx, key)
+		return err
+	})
+	return value, err
+}
+
+func (s *MultiService) PutData(ctx context.Context, key string, value string) error {
+	return s.quorum(ctx, "write", func(ctx context.Context, svc ExternalService) error { return svc.PutData(ctx, key, value) })
+}
+
+func (s *MultiService) DeleteData(ctx context.Context, key string) error {
+	return s.quorum(ctx, "delete", func(ctx context.Context, svc ExternalService) error { return svc.DeleteData(ctx, key) })
+}
+
+// ListKeys returns the sorted keys of the first replica that answers.
+func (s *MultiService) ListKeys(ctx context.Context) (keys []string, err error) {
+	err = s.failover(ctx, func(ctx context.Context, svc ExternalService) error {
+		keys, err = svc.ListKeys(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// call runs fn against r, failing without reaching it while r is
+// partitioned or too little of ctx's deadline is left to start a call.
+func (s *MultiService) call(ctx context.Context, r Replica, fn func(context.Context, ExternalService) error) error {
+	if err := s.budget(ctx); err != nil {
+		return fmt.Errorf("replica %s skipped: %w", r.Name, err)
+	}
+	if !s.Reachable(r.Name) {
+		return fmt.Errorf("replica %s unreachable (network partition): %w", r.Name, ErrUnavailable)
+	}
+	if err := fn(ctx, r.Service); err != nil {
+		return fmt.Errorf("replica %s: %w", r.Name, err)
+	}
+	return nil
+}
+
+// budget returns an error if ctx is done or its deadline is less than
+// MinCallTime away.
+func (s *MultiService) budget(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	if left := time.Until(deadline); left < s.MinCallTime {
+		return fmt.Errorf("%v left before the deadline, need %v: %w", left.Round(time.Millisecond), s.MinCallTime, context.DeadlineExceeded)
+	}
+	return nil
+}
+
+// quorum calls fn on every replica concurrently and succeeds if at least
+// WriteQuorum of the calls did. It returns once every call has finished
+// or ctx is done, counting calls still running at that point as failed
+// and canceling them.
+func (s *MultiService) quorum(ctx context.Context, what string, fn func(context.Context, ExternalService) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		i   int
+		err error
+	}
+	results := make(chan result, len(s.replicas))
+	for i, r := range s.replicas {
+		i, r := i, r
+		go func() {
+			results <- result{i, s.call(ctx, r, fn)}
+		}()
+	}
+
+	errs := make([]error, len(s.replicas))
+	done := make([]bool, len(s.replicas))
+	for pending := len(s.replicas); pending > 0; pending-- {
+		select {
+		case res := <-results:
+			errs[res.i], done[res.i] = res.err, true
+			continue
+		case <-ctx.Done():
+		}
+		for i, r := range s.replicas {
+			if !done[i] {
+				errs[i] = fmt.Errorf("replica %s: %w", r.Name, ctx.Err())
+			}
+		}
+		break
+	}
+
+	acked := 0
+	for _, err := range errs {
+		if err == nil {
+			acked++
+		}
+	}
+	if acked >= s.WriteQuorum {
+		return nil
+	}
+	return fmt.Errorf("%s reached %d of %d replicas, quorum is %d: %w",
+		what, acked, len(s.replicas), s.WriteQuorum, errors.Join(errs...))
+}
+
+// failover calls fn on each replica in turn until one succeeds or fails
+// with an error that is not worth retrying elsewhere.
+func (s *MultiService) failover(ctx context.Context, fn func(context.Context, ExternalService) error) error {
+	var errs []error
+	for _, r := range s.replicas {
+		err := s.call(ctx, r, fn)
+		if err == nil || !IsRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return fmt.Errorf("no replicas: %w", ErrUnavailable)
+	}
+	return errors.Join(errs...)
+}
+
+func (s *MultiService) replica(name string) *Replica {
+	for i := range s.replicas {
+		if s.replicas[i].Name == name {
+			return &s.replicas[i]
+		}
+	}
+	return nil
+}
+
+func (s *MultiService) now() time.Time {
+	if s.Clock == nil {
+		return time.Now()
+	}
+	return s.Clock.Now()
+}
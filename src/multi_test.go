@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newReplicas returns three dry-run MockServices and a MultiService over
+// them, in the order a, b, c.
+func newReplicas(clock Clock) (*MultiService, map[string]*MockService) {
+	backends := map[string]*MockService{}
+	var replicas []Replica
+	for _, name := range []string{"a", "b", "c"} {
+		backends[name] = NewMockService(name, WithDryRun(true))
+		replicas = append(replicas, Replica{Name: name, Service: backends[name]})
+	}
+	multi := NewMultiService(replicas...)
+	multi.Clock = clock
+	return multi, backends
+}
+
+func TestMultiServicePartition(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(clockEpoch)
+	multi, backends := newReplicas(clock)
+
+	if err := multi.PutData(ctx, "before", "v1"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	if err := multi.Partition([]string{"a"}, time.Minute); err != nil {
+		t.Fatalf("Partition failed: %v", err)
+	}
+	if multi.Reachable("a") || !multi.Reachable("b") {
+		t.Fatal("Expected only a to be partitioned")
+	}
+
+	// Two of three replicas still make a write quorum.
+	if err := multi.PutData(ctx, "during", "v2"); err != nil {
+		t.Fatalf("Expected the write to meet quorum, got %v", err)
+	}
+	if _, err := backends["a"].GetData(ctx, "during"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the partitioned replica to miss the write, got %v", err)
+	}
+	for _, name := range []string{"b", "c"} {
+		if got, err := backends[name].GetData(ctx, "during"); err != nil || got != "v2" {
+			t.Errorf("Expected replica %s to hold the write, got %q, %v", name, got, err)
+		}
+	}
+
+	// Reads fail over from a, the first replica, to b.
+	backends["a"].ResetMetrics()
+	if got, err := multi.GetData(ctx, "before"); err != nil || got != "v1" {
+		t.Errorf("Expected the read to fail over, got %q, %v", got, err)
+	}
+	if got, err := multi.GetData(ctx, "during"); err != nil || got != "v2" {
+		t.Errorf("Expected the read to fail over, got %q, %v", got, err)
+	}
+	if got := backends["a"].Metrics()["GetData"].Count; got != 0 {
+		t.Errorf("Expected no calls to reach the partitioned replica, got %d", got)
+	}
+
+	clock.Advance(time.Minute)
+	if !multi.Reachable("a") {
+		t.Error("Expected a to be reachable once the window elapsed")
+	}
+	if _, err := multi.GetData(ctx, "during"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the healed replica to answer again, got %v", err)
+	}
+}
+
+func TestMultiServiceLosesQuorum(t *testing.T) {
+	ctx := context.Background()
+	multi, _ := newReplicas(NewFakeClock(clockEpoch))
+
+	if err := multi.Partition([]string{"a", "b"}, time.Minute); err != nil {
+		t.Fatalf("Partition failed: %v", err)
+	}
+	err := multi.PutData(ctx, "key", "value")
+	if err == nil || !strings.Contains(err.Error(), "reached 1 of 3 replicas, quorum is 2") {
+		t.Fatalf("Expected the write to miss quorum, got %v", err)
+	}
+	if !errors.Is(err, ErrUnavailable) || !IsRetryable(err) {
+		t.Errorf("Expected a retryable ErrUnavailable, got %v", err)
+	}
+	if got, err := multi.GetData(ctx, "key"); err != nil || got != "value" {
+		t.Errorf("Expected c to serve the partial write, got %q, %v", got, err)
+	}
+
+	if err := multi.Partition([]string{"z"}, time.Minute); err == nil {
+		t.Error("Expected partitioning an unknown replica to fail")
+	}
+}
+
+// stallingService blocks PutData and GetData until release is closed,
+// ignoring the caller's context like a backend with no deadline support.
+// It records the deadline of every call it sees.
+type stallingService struct {
+	ExternalService
+	release   chan struct{}
+	deadlines chan time.Time
+}
+
+func (s *stallingService) PutData(ctx context.Context, key, value string) error {
+	s.record(ctx)
+	<-s.release
+	return s.ExternalService.PutData(ctx, key, value)
+}
+
+func (s *stallingService) GetData(ctx context.Context, key string) (string, error) {
+	s.record(ctx)
+	<-s.release
+	return s.ExternalService.GetData(ctx, key)
+}
+
+func (s *stallingService) record(ctx context.Context) {
+	deadline, _ := ctx.Deadline()
+	s.deadlines <- deadline
+}
+
+func TestMultiServiceDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	deadlines := make(chan time.Time, 10)
+	stall := func(name string) Replica {
+		return Replica{Name: name, Service: &stallingService{
+			ExternalService: NewMockService(name, WithDryRun(true)),
+			release:         release,
+			deadlines:       deadlines,
+		}}
+	}
+	multi := NewMultiService(
+		Replica{Name: "a", Service: NewMockService("a", WithDryRun(true))},
+		stall("b"),
+		stall("c"),
+	)
+
+	const timeout = 50 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	parent, _ := ctx.Deadline()
+
+	start := time.Now()
+	err := multi.PutData(ctx, "key", "value")
+	if elapsed := time.Since(start); elapsed > timeout+100*time.Millisecond {
+		t.Errorf("Expected the write to return by the %v deadline, took %v", timeout, elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "reached 1 of 3 replicas") {
+		t.Errorf("Expected the stalled replicas to miss the deadline, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if got := <-deadlines; !got.Equal(parent) {
+			t.Errorf("Expected replicas to share the caller's deadline %v, got %v", parent, got)
+		}
+	}
+}
+
+func TestMultiServiceMinCallTime(t *testing.T) {
+	multi, backends := newReplicas(NewFakeClock(clockEpoch))
+	multi.MinCallTime = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := multi.GetData(ctx, "key"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a near deadline to short-circuit the read, got %v", err)
+	}
+	if err := multi.PutData(ctx, "key", "value"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a near deadline to short-circuit the write, got %v", err)
+	}
+	for name, backend := range backends {
+		if n := len(backend.Metrics()); n != 0 {
+			t.Errorf("Expected replica %s not to be called, got %d calls", name, n)
+		}
+	}
+
+	// Without a deadline there is no budget to run out of.
+	if err := multi.PutData(context.Background(), "key", "value"); err != nil {
+		t.Errorf("Expected a write without a deadline to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"log/slog"
+	"math/rand"
+	"regexp"
+	"time"
+)
+
+// Option configures a MockService created by NewMockService.
+type Option func(*MockService)
+
+// WithResponseTime sets the simulated latency of each operation.
+func WithResponseTime(d time.Duration) Option {
+	return func(m *MockService) {
+		m.responseTime = d
+	}
+}
+
+// WithConnectTime sets the simulated latency of Connect, which otherwise
+// takes as long as any other operation.
+func WithConnectTime(d time.Duration) Option {
+	return func(m *MockService) {
+		m.connectTime = &d
+	}
+}
+
+// WithFailureRate sets the probability, in [0, 1], that an operation fails.
+func WithFailureRate(rate float32) Option {
+	return func(m *MockService) {
+		m.failureRate = rate
+	}
+}
+
+// WithSeed makes failure simulation deterministic by drawing from a source
+// seeded with seed instead of the global random source.
+func WithSeed(seed int64) Option {
+	return func(m *MockService) {
+		m.rng = rand.New(rand.NewSource(seed))
+	}
+}
+
+// WithRetries makes the service retry a simulated failure up to n times
+// before reporting it, as an SDK's built-in retries would. The effective
+// failure rate becomes failureRate^(n+1).
+func WithRetries(n int) Option {
+	return func(m *MockService) {
+		m.retries = n
+	}
+}
+
+// WithNamespace sets the service's Namespace.
+func WithNamespace(ns string) Option {
+	return func(m *MockService) {
+		m.Namespace = ns
+	}
+}
+
+// WithDryRun sets the service's DryRun mode.
+func WithDryRun(dryRun bool) Option {
+	return func(m *MockService) {
+		m.DryRun = dryRun
+	}
+}
+
+// WithOnOperation sets the service's OnOperation hook.
+func WithOnOperation(fn func(op string, key string, dur time.Duration, err error)) Option {
+	return func(m *MockService) {
+		m.OnOperation = fn
+	}
+}
+
+// WithLogger sets the service's operation Logger.
+func WithLogger(l *slog.Logger) Option {
+	return func(m *MockService) {
+		m.Logger = l
+	}
+}
+
+// WithPoolSize sets the service's PoolSize.
+func WithPoolSize(n int) Option {
+	return func(m *MockService) {
+		m.PoolSize = n
+	}
+}
+
+// WithMaxConcurrency caps how many operations the service processes at
+// once. It sets PoolSize: excess operations wait for a free slot, or fail
+// with ErrBusy if RejectWhenBusy is set. Unlike RateLimit, which counts
+// operations per second, it bounds operations in flight.
+func WithMaxConcurrency(n int) Option {
+	return WithPoolSize(n)
+}
+
+// WithRejectWhenBusy sets the service's RejectWhenBusy.
+func WithRejectWhenBusy(reject bool) Option {
+	return func(m *MockService) {
+		m.RejectWhenBusy = reject
+	}
+}
+
+// WithPoolTimeout sets the service's PoolTimeout.
+func WithPoolTimeout(d time.Duration) Option {
+	return func(m *MockService) {
+		m.PoolTimeout = d
+	}
+}
+
+// WithWarmup sets the service's WarmupCalls and WarmupFactor.
+func WithWarmup(calls int, factor float64) Option {
+	return func(m *MockService) {
+		m.WarmupCalls = calls
+		m.WarmupFactor = factor
+	}
+}
+
+// WithMaxValueSize sets the service's MaxValueSize.
+func WithMaxValueSize(n int) Option {
+	return func(m *MockService) {
+		m.MaxValueSize = n
+	}
+}
+
+// WithMaxKeyLength sets the service's MaxKeyLength.
+func WithMaxKeyLength(n int) Option {
+	return func(m *MockService) {
+		m.MaxKeyLength = n
+	}
+}
+
+// WithKeyPattern sets the service's KeyPattern.
+func WithKeyPattern(pattern *regexp.Regexp) Option {
+	return func(m *MockService) {
+		m.KeyPattern = pattern
+	}
+}
+
+// WithBandwidth sets the service's BytesPerSecond.
+func WithBandwidth(bytesPerSecond int) Option {
+	return func(m *MockService) {
+		m.BytesPerSecond = bytesPerSecond
+	}
+}
+
+// WithCorruptionRate sets the service's CorruptionRate.
+func WithCorruptionRate(rate float32) Option {
+	return func(m *MockService) {
+		m.CorruptionRate = rate
+	}
+}
+
+// WithReplicationLag sets the service's ReplicationLag.
+func WithReplicationLag(lag time.Duration) Option {
+	return func(m *MockService) {
+		m.ReplicationLag = lag
+	}
+}
+
+// WithClock makes the service read the time and simulate latency through
+// clock instead of the system clock.
+func WithClock(clock Clock) Option {
+	return func(m *MockService) {
+		m.clock = clock
+	}
+}
+
+// WithMaxKeys sets the service's MaxKeys and Eviction policy.
+func WithMaxKeys(n int, policy EvictionPolicy) Option {
+	return func(m *MockService) {
+		m.MaxKeys = n
+		m.Eviction = policy
+	}
+}
+
+// WithStrictConnect sets the service's StrictConnect.
+func WithStrictConnect(strict bool) Option {
+	return func(m *MockService) {
+		m.StrictConnect = strict
+	}
+}
+
+// WithBlockWhilePaused sets the service's BlockWhilePaused.
+func WithBlockWhilePaused(block bool) Option {
+	return func(m *MockService) {
+		m.BlockWhilePaused = block
+	}
+}
+
+// WithRateLimit sets the service's RateLimit.
+func WithRateLimit(perSecond int) Option {
+	return func(m *MockService) {
+		m.RateLimit = perSecond
+	}
+}
+
+// WithTruncateAbove sets the service's TruncateAbove.
+func WithTruncateAbove(n int) Option {
+	return func(m *MockService) {
+		m.TruncateAbove = n
+	}
+}
+
+// WithResponseTemplate sets the service's ResponseTemplate.
+func WithResponseTemplate(enabled bool) Option {
+	return func(m *MockService) {
+		m.ResponseTemplate = enabled
+	}
+}
+
+// WithErrorFormatter sets the service's ErrorFormatter.
+func WithErrorFormatter(f ErrorFormatter) Option {
+	return func(m *MockService) {
+		m.ErrorFormatter = f
+	}
+}
+
+// WithStore replaces the default in-memory Store. Passing the same Store to
+// several services lets them share data; give each a Namespace to keep
+// their keys apart.
+func WithStore(s Store) Option {
+	return func(m *MockService) {
+		m.store = s
+	}
+}
+
+// WithTracer sets the service's Tracer.
+func WithTracer(tr Tracer) Option {
+	return func(m *MockService) {
+		m.Tracer = tr
+	}
+}
+
+// WithColdStartDelay sets the service's ColdStartDelay.
+func WithColdStartDelay(d time.Duration) Option {
+	return func(m *MockService) {
+		m.ColdStartDelay = d
+	}
+}
+
+// WithReadinessDelay sets the service's ReadinessDelay.
+func WithReadinessDelay(d time.Duration) Option {
+	return func(m *MockService) {
+		m.ReadinessDelay = d
+	}
+}
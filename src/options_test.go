@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestNewMockServiceOptions(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		svc := NewMockService("Default")
+		if svc.responseTime != 0 || svc.failureRate != 0 || svc.rng != nil {
+			t.Errorf("Unexpected defaults: %+v", svc)
+		}
+	})
+
+	t.Run("latency and failure", func(t *testing.T) {
+		svc := NewMockService("Configured",
+			WithResponseTime(25*time.Millisecond),
+			WithFailureRate(0.5),
+			WithRetries(2),
+		)
+		if svc.responseTime != 25*time.Millisecond {
+			t.Errorf("Expected response time 25ms, got %v", svc.responseTime)
+		}
+		if svc.failureRate != 0.5 {
+			t.Errorf("Expected failure rate 0.5, got %v", svc.failureRate)
+		}
+		if svc.retries != 2 {
+			t.Errorf("Expected 2 retries, got %d", svc.retries)
+		}
+	})
+
+	t.Run("behaviour fields", func(t *testing.T) {
+		logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+		svc := NewMockService("Configured",
+			WithNamespace("ns"),
+			WithDryRun(true),
+			WithLogger(logger),
+			WithPoolSize(4),
+			WithWarmup(3, 2.5),
+			WithMaxValueSize(1024),
+			WithRateLimit(10),
+			WithReplicationLag(time.Second),
+			WithMaxKeyLength(64),
+			WithKeyPattern(regexp.MustCompile(`^[a-z]+$`)),
+		)
+		if svc.Namespace != "ns" || !svc.DryRun || svc.Logger != logger || svc.PoolSize != 4 {
+			t.Errorf("Unexpected configuration: %+v", svc)
+		}
+		if svc.WarmupCalls != 3 || svc.WarmupFactor != 2.5 {
+			t.Errorf("Unexpected warmup: %d, %v", svc.WarmupCalls, svc.WarmupFactor)
+		}
+		if svc.MaxValueSize != 1024 || svc.RateLimit != 10 {
+			t.Errorf("Unexpected limits: %d, %d", svc.MaxValueSize, svc.RateLimit)
+		}
+		if svc.ReplicationLag != time.Second {
+			t.Errorf("Expected replication lag 1s, got %v", svc.ReplicationLag)
+		}
+		if svc.MaxKeyLength != 64 || svc.KeyPattern.String() != `^[a-z]+$` {
+			t.Errorf("Unexpected key rules: %d, %v", svc.MaxKeyLength, svc.KeyPattern)
+		}
+	})
+
+	t.Run("basic constructor is equivalent", func(t *testing.T) {
+		svc := NewBasicMockService("Basic", 10*time.Millisecond, 0.2)
+		if svc.responseTime != 10*time.Millisecond || svc.failureRate != 0.2 {
+			t.Errorf("Unexpected configuration: %+v", svc)
+		}
+	})
+}
+
+func TestWithSeedIsReproducible(t *testing.T) {
+	ctx := context.Background()
+	outcomes := func() []bool {
+		svc := NewMockService("Seeded", WithFailureRate(0.5), WithSeed(42))
+		results := make([]bool, 50)
+		for i := range results {
+			results[i] = svc.Ping(ctx) == nil
+		}
+		return results
+	}
+
+	first, second := outcomes(), outcomes()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("Outcome %d differs between runs with the same seed", i)
+		}
+	}
+}
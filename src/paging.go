@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ListKeysPaged returns up to pageSize keys in sorted order, starting
+// after the position encoded in token, and a token for the next page. An
+// empty token starts from the first key; an empty nextToken means there are
+// no more keys. Like an S3 continuation token, a page token records the
+// last key returned, so keys written or deleted between pages neither
+// shift later pages nor repeat earlier ones.
+func (m *MockService) ListKeysPaged(ctx context.Context, pageSize int, token string) (_ []string, nextToken string, err error) {
+	defer m.observe(ctx, m.startOp("ListKeysPaged", ""), &err)
+	if pageSize <= 0 {
+		return nil, "", fmt.Errorf("invalid page size %d: %w", pageSize, ErrPermanent)
+	}
+	after, ok, err := decodePageToken(token)
+	if err != nil {
+		return nil, "", err
+	}
+	release, err := m.begin(ctx, m.warmupLatency(m.responseTime))
+	if err != nil {
+		return nil, "", err
+	}
+	defer release()
+	if m.shouldFail() {
+		return nil, "", fmt.Errorf("failed to list keys from %s: %w", m.name, ErrTransient)
+	}
+
+	m.mu.Lock()
+	keys, err := m.keys()
+	m.mu.Unlock()
+	if err != nil {
+		return nil, "", err
+	}
+	if ok {
+		keys = keys[sort.Search(len(keys), func(i int) bool { return keys[i] > after }):]
+	}
+	if len(keys) <= pageSize {
+		return keys, "", nil
+	}
+	page := keys[:pageSize:pageSize]
+	return page, encodePageToken(page[pageSize-1]), nil
+}
+
+// pageTokenPrefix starts every encoded position, so that a token is never
+// empty, even after the key "".
+const pageTokenPrefix = "k"
+
+// encodePageToken returns the token for the position after key after.
+func encodePageToken(after string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(pageTokenPrefix + after))
+}
+
+// decodePageToken returns the key a token resumes after, and false for the
+// empty token, which starts from the first key.
+func decodePageToken(token string) (after string, ok bool, err error) {
+	if token == "" {
+		return "", false, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || !strings.HasPrefix(string(b), pageTokenPrefix) {
+		return "", false, fmt.Errorf("invalid page token %q: %w", token, ErrPermanent)
+	}
+	return strings.TrimPrefix(string(b), pageTokenPrefix), true, nil
+}
+
+// ListKeysMaybeTruncated lists the service's keys like ListKeys, except
+// that while the service is under more load than TruncateAbove allows it
+// degrades gracefully: rather than failing, it returns only the first half
+// of the keys and reports truncated as true. Load is measured as the call
+// arrives.
+func (m *MockService) ListKeysMaybeTruncated(ctx context.Context) (_ []string, truncated bool, err error) {
+	defer m.observe(ctx, m.startOp("ListKeysMaybeTruncated", ""), &err)
+	load := int(m.inFlight.Load()) + 1
+	release, err := m.begin(ctx, m.warmupLatency(m.responseTime))
+	if err != nil {
+		return nil, false, err
+	}
+	defer release()
+	if m.shouldFail() {
+		return nil, false, fmt.Errorf("failed to list keys from %s: %w", m.name, ErrTransient)
+	}
+
+	m.mu.Lock()
+	keys, err := m.keys()
+	m.mu.Unlock()
+	if err != nil {
+		return nil, false, err
+	}
+	if m.TruncateAbove > 0 && load > m.TruncateAbove {
+		return keys[:len(keys)/2], true, nil
+	}
+	return keys, false, nil
+}
+
+// ListKeysStream sends the service's keys on the returned key channel,
+// for callers that process keys one at a time rather than holding them all.
+// The key channel is closed when the listing ends, after which the error
+// channel yields the error that ended it, if any, and is closed. Once ctx
+// is done no further keys are sent and the error is ctx's error, so a
+// consumer that stops reading must cancel ctx to release the stream.
+func (m *MockService) ListKeysStream(ctx context.Context) (<-chan string, <-chan error) {
+	keys := make(chan string)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(keys)
+		if err := m.streamKeys(ctx, keys); err != nil {
+			errc <- err
+		}
+	}()
+	return keys, errc
+}
+
+func (m *MockService) streamKeys(ctx context.Context, out chan<- string) (err error) {
+	defer m.observe(ctx, m.startOp("ListKeysStream", ""), &err)
+	release, err := m.begin(ctx, m.warmupLatency(m.responseTime))
+	if err != nil {
+		return err
+	}
+	defer release()
+	if m.shouldFail() {
+		return fmt.Errorf("failed to list keys from %s: %w", m.name, ErrTransient)
+	}
+
+	m.mu.Lock()
+	keys, err := m.keys()
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		select {
+		case out <- k:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
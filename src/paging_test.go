@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestListKeysPaged(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Paged", WithDryRun(true))
+	for i := 0; i < 23; i++ {
+		if err := svc.PutData(ctx, fmt.Sprintf("key-%02d", i), "value"); err != nil {
+			t.Fatalf("PutData failed: %v", err)
+		}
+	}
+	all, err := svc.ListKeys(ctx)
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+
+	for _, pageSize := range []int{1, 5, 23, 50} {
+		t.Run(fmt.Sprintf("page size %d", pageSize), func(t *testing.T) {
+			var got []string
+			token, pages := "", 0
+			for {
+				keys, next, err := svc.ListKeysPaged(ctx, pageSize, token)
+				if err != nil {
+					t.Fatalf("ListKeysPaged failed on page %d: %v", pages, err)
+				}
+				if len(keys) > pageSize {
+					t.Fatalf("Page %d has %d keys, more than %d", pages, len(keys), pageSize)
+				}
+				got = append(got, keys...)
+				pages++
+				if next == "" {
+					break
+				}
+				token = next
+			}
+			if !reflect.DeepEqual(got, all) {
+				t.Errorf("Expected pages to concatenate to %v, got %v", all, got)
+			}
+			if want := (len(all) + pageSize - 1) / pageSize; pages != want {
+				t.Errorf("Expected %d pages, got %d", want, pages)
+			}
+		})
+	}
+}
+
+func TestListKeysPagedConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Paged", WithDryRun(true))
+	for _, key := range []string{"a", "c", "e", "g"} {
+		if err := svc.PutData(ctx, key, "value"); err != nil {
+			t.Fatalf("PutData failed: %v", err)
+		}
+	}
+
+	first, token, err := svc.ListKeysPaged(ctx, 2, "")
+	if err != nil || !reflect.DeepEqual(first, []string{"a", "c"}) {
+		t.Fatalf("Expected the first page [a c], got %v, %v", first, err)
+	}
+	// Changes before the token's position do not shift the next page.
+	if err := svc.DeleteData(ctx, "a"); err != nil {
+		t.Fatalf("DeleteData failed: %v", err)
+	}
+	if err := svc.PutData(ctx, "b", "value"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	second, token, err := svc.ListKeysPaged(ctx, 2, token)
+	if err != nil || !reflect.DeepEqual(second, []string{"e", "g"}) || token != "" {
+		t.Errorf("Expected the last page [e g], got %v, %q, %v", second, token, err)
+	}
+}
+
+func TestListKeysPagedInvalid(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Paged", WithDryRun(true))
+	if _, _, err := svc.ListKeysPaged(ctx, 0, ""); !errors.Is(err, ErrPermanent) {
+		t.Errorf("Expected a permanent error for a zero page size, got %v", err)
+	}
+	if _, _, err := svc.ListKeysPaged(ctx, 10, "not a token!"); !errors.Is(err, ErrPermanent) {
+		t.Errorf("Expected a permanent error for a malformed token, got %v", err)
+	}
+	keys, next, err := svc.ListKeysPaged(ctx, 10, "")
+	if err != nil || len(keys) != 0 || next != "" {
+		t.Errorf("Expected an empty last page, got %v, %q, %v", keys, next, err)
+	}
+}
+
+func TestListKeysStream(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Streamed", WithDryRun(true))
+	for i := 0; i < 50; i++ {
+		if err := svc.PutData(ctx, fmt.Sprintf("key-%02d", i), "value"); err != nil {
+			t.Fatalf("PutData failed: %v", err)
+		}
+	}
+	want, err := svc.ListKeys(ctx)
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+
+	keys, errc := svc.ListKeysStream(ctx)
+	var got []string
+	for k := range keys {
+		got = append(got, k)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("Expected the stream to end cleanly, got %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected streamed keys %v, got %v", want, got)
+	}
+
+	flaky := NewMockService("Flaky", WithFailureRate(1))
+	keys, errc = flaky.ListKeysStream(ctx)
+	if _, ok := <-keys; ok {
+		t.Error("Expected a failed listing to send no keys")
+	}
+	if err := <-errc; !errors.Is(err, ErrTransient) {
+		t.Errorf("Expected ErrTransient, got %v", err)
+	}
+}
+
+func TestListKeysStreamCancel(t *testing.T) {
+	svc := NewMockService("Streamed", WithDryRun(true))
+	for i := 0; i < 100; i++ {
+		if err := svc.PutData(context.Background(), fmt.Sprintf("key-%03d", i), "value"); err != nil {
+			t.Fatalf("PutData failed: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	keys, errc := svc.ListKeysStream(ctx)
+	for i := 0; i < 3; i++ {
+		<-keys
+	}
+	cancel()
+
+	received := 0
+	timeout := time.After(time.Second)
+	for done := false; !done; {
+		select {
+		case _, ok := <-keys:
+			if !ok {
+				done = true
+				break
+			}
+			received++
+		case <-timeout:
+			t.Fatal("Expected the stream to close promptly after cancellation")
+		}
+	}
+	// A key already offered when ctx was canceled may still be delivered.
+	if received > 1 {
+		t.Errorf("Expected at most 1 key after cancellation, got %d", received)
+	}
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestListKeysMaybeTruncated(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(clockEpoch)
+	svc := NewMockService("Degraded", WithClock(clock), WithResponseTime(time.Second), WithTruncateAbove(2))
+	for i := 0; i < 10; i++ {
+		if err := svc.store.Set(fmt.Sprintf("key-%d", i), "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitFor := func(n int) {
+		for clock.Waiters() < n {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	type listing struct {
+		keys      []string
+		truncated bool
+		err       error
+	}
+	list := func(busy int) listing {
+		done := make(chan listing, 1)
+		go func() {
+			keys, truncated, err := svc.ListKeysMaybeTruncated(ctx)
+			done <- listing{keys, truncated, err}
+		}()
+		waitFor(busy + 1)
+		clock.Advance(time.Second)
+		return <-done
+	}
+
+	quiet := list(0)
+	if quiet.err != nil || quiet.truncated || len(quiet.keys) != 10 {
+		t.Errorf("Expected a quiet service to list all 10 keys, got %d, truncated=%v, %v", len(quiet.keys), quiet.truncated, quiet.err)
+	}
+
+	// Three pings in flight plus the listing exceed the threshold of 2.
+	var pings sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		pings.Add(1)
+		go func() {
+			defer pings.Done()
+			svc.Ping(ctx)
+		}()
+	}
+	waitFor(3)
+	loaded := list(3)
+	pings.Wait()
+	if loaded.err != nil || !loaded.truncated || len(loaded.keys) != 5 {
+		t.Errorf("Expected a loaded service to list 5 keys, truncated, got %d, truncated=%v, %v", len(loaded.keys), loaded.truncated, loaded.err)
+	}
+	if !reflect.DeepEqual(loaded.keys, quiet.keys[:5]) {
+		t.Errorf("Expected the truncated listing to be a prefix of the full one, got %v", loaded.keys)
+	}
+
+	if n := svc.inFlight.Load(); n != 0 {
+		t.Errorf("Expected no operations in flight afterwards, got %d", n)
+	}
+}
+
+func TestListKeysPagedEmptyKey(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Paged")
+	for _, key := range []string{"", "a", "b"} {
+		if err := svc.PutData(ctx, key, "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first, token, err := svc.ListKeysPaged(ctx, 1, "")
+	if err != nil || !reflect.DeepEqual(first, []string{""}) || token == "" {
+		t.Fatalf("Expected the page [\"\"] and a next token, got %q, %q, %v", first, token, err)
+	}
+	rest, token, err := svc.ListKeysPaged(ctx, 2, token)
+	if err != nil || !reflect.DeepEqual(rest, []string{"a", "b"}) || token != "" {
+		t.Errorf("Expected the last page [a b], got %v, %q, %v", rest, token, err)
+	}
+}
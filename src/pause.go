@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// Pause takes the service down: until Resume is called, every operation
+// fails with ErrUnavailable, or waits for Resume if BlockWhilePaused is
+// set, regardless of the failure rate. Pausing a paused service has no
+// effect.
+func (m *MockService) Pause() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.resumed == nil {
+		m.resumed = make(chan struct{})
+	}
+}
+
+// Resume brings a paused service back up, releasing any operations waiting
+// on it. Resuming a running service has no effect.
+func (m *MockService) Resume() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.resumed != nil {
+		close(m.resumed)
+		m.resumed = nil
+	}
+}
+
+// Paused reports whether the service is paused.
+func (m *MockService) Paused() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.resumed != nil
+}
+
+// waitIfPaused fails with ErrUnavailable while the service is paused, or
+// with BlockWhilePaused waits until it is resumed or ctx is done.
+func (m *MockService) waitIfPaused(ctx context.Context) error {
+	m.mu.Lock()
+	resumed := m.resumed
+	m.mu.Unlock()
+	if resumed == nil {
+		return nil
+	}
+	if !m.BlockWhilePaused {
+		return fmt.Errorf("%s is paused: %w", m.name, ErrUnavailable)
+	}
+	select {
+	case <-resumed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
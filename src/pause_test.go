@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMockServicePauseResume(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Pausable")
+	if err := svc.PutData(ctx, "key", "value"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+
+	svc.Pause()
+	if !svc.Paused() {
+		t.Error("Expected the service to report it is paused")
+	}
+	ops := map[string]error{
+		"Connect": svc.Connect(ctx),
+		"Ping":    svc.Ping(ctx),
+		"GetData": second(svc.GetData(ctx, "key")),
+		"PutData": svc.PutData(ctx, "key", "other"),
+	}
+	for op, err := range ops {
+		if !errors.Is(err, ErrUnavailable) {
+			t.Errorf("%s: expected ErrUnavailable while paused, got %v", op, err)
+		}
+	}
+
+	svc.Resume()
+	if svc.Paused() {
+		t.Error("Expected the service to report it is running")
+	}
+	got, err := svc.GetData(ctx, "key")
+	if err != nil {
+		t.Fatalf("Expected GetData to succeed after Resume, got %v", err)
+	}
+	if got != "value" {
+		t.Errorf("Expected the write made while paused to be rejected, got %q", got)
+	}
+}
+
+func TestMockServiceBlockWhilePaused(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Pausable", WithBlockWhilePaused(true))
+	svc.Pause()
+
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = svc.Ping(ctx)
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("Call %d: expected to block while paused, got %v", i, err)
+		}
+	}
+	svc.Resume()
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("Call %d: expected success after Resume, got %v", i, err)
+		}
+	}
+
+	svc.Pause()
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := svc.Ping(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a blocked call to honor its context, got %v", err)
+	}
+}
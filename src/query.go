@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// QueryOptions selects a window of keys from QueryKeys, like the WHERE,
+// ORDER BY, OFFSET and LIMIT clauses of a SQL query.
+type QueryOptions struct {
+	// Prefix restricts the results to keys starting with it.
+	Prefix string
+	// Offset skips that many matching keys.
+	Offset int
+	// Limit caps the number of keys returned. Zero means no limit.
+	Limit int
+	// SortDescending orders keys from last to first instead of first to
+	// last.
+	SortDescending bool
+}
+
+// QueryKeys returns the keys matching opts.Prefix, sorted and then windowed
+// by opts.Offset and opts.Limit. Paging by offset, unlike ListKeysPaged,
+// shifts later pages when keys before them are written or deleted, as it
+// does in a real database. A negative offset or limit is an error.
+func (d *DatabaseService) QueryKeys(ctx context.Context, opts QueryOptions) (_ []string, err error) {
+	m := d.MockService
+	defer m.observe(ctx, m.startOp("QueryKeys", ""), &err)
+	if opts.Offset < 0 {
+		return nil, fmt.Errorf("invalid offset %d: %w", opts.Offset, ErrPermanent)
+	}
+	if opts.Limit < 0 {
+		return nil, fmt.Errorf("invalid limit %d: %w", opts.Limit, ErrPermanent)
+	}
+	release, err := m.begin(ctx, m.warmupLatency(m.responseTime))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if m.shouldFail() {
+		return nil, fmt.Errorf("failed to query keys from %s: %w", m.name, ErrTransient)
+	}
+
+	m.mu.Lock()
+	keys, err := m.keys()
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	matched := keys[:0]
+	for _, k := range keys {
+		if strings.HasPrefix(k, opts.Prefix) {
+			matched = append(matched, k)
+		}
+	}
+	if opts.SortDescending {
+		for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+			matched[i], matched[j] = matched[j], matched[i]
+		}
+	}
+	if opts.Offset >= len(matched) {
+		return []string{}, nil
+	}
+	matched = matched[opts.Offset:]
+	if opts.Limit > 0 && opts.Limit < len(matched) {
+		matched = matched[:opts.Limit]
+	}
+	return matched, nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestQueryKeys(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	db.DryRun = true
+	for _, key := range []string{"user/3", "order/1", "user/1", "user/4", "order/2", "user/2", "user/5"} {
+		if err := db.PutData(ctx, key, "row"); err != nil {
+			t.Fatalf("PutData failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts QueryOptions
+		want []string
+	}{
+		{"all", QueryOptions{}, []string{"order/1", "order/2", "user/1", "user/2", "user/3", "user/4", "user/5"}},
+		{"prefix", QueryOptions{Prefix: "user/"}, []string{"user/1", "user/2", "user/3", "user/4", "user/5"}},
+		{"first page", QueryOptions{Prefix: "user/", Limit: 2}, []string{"user/1", "user/2"}},
+		{"middle page", QueryOptions{Prefix: "user/", Offset: 2, Limit: 2}, []string{"user/3", "user/4"}},
+		{"short last page", QueryOptions{Prefix: "user/", Offset: 4, Limit: 2}, []string{"user/5"}},
+		{"past the end", QueryOptions{Prefix: "user/", Offset: 5, Limit: 2}, []string{}},
+		{"descending", QueryOptions{Prefix: "user/", SortDescending: true}, []string{"user/5", "user/4", "user/3", "user/2", "user/1"}},
+		{"descending window", QueryOptions{Prefix: "user/", Offset: 1, Limit: 2, SortDescending: true}, []string{"user/4", "user/3"}},
+		{"no match", QueryOptions{Prefix: "invoice/"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.QueryKeys(ctx, tt.opts)
+			if err != nil {
+				t.Fatalf("QueryKeys failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestQueryKeysInvalid(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	for _, opts := range []QueryOptions{{Offset: -1}, {Limit: -1}} {
+		if _, err := db.QueryKeys(ctx, opts); !errors.Is(err, ErrPermanent) {
+			t.Errorf("QueryKeys(%+v): expected a permanent error, got %v", opts, err)
+		}
+	}
+}
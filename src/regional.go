@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Region is a named regional deployment of a service behind a
+// RegionalService.
+type Region struct {
+	Name    string
+	Service ExternalService
+}
+
+// RegionalService is an ExternalService that sends every call to a single
+// active region. After FailoverThreshold calls in a row fail with
+// retryable errors it fails over to the next region, in the order given,
+// wrapping around after the last. Calls made after a failover, writes
+// included, go to the new region; there is no automatic failback. Connect
+// connects every region so that a failover lands on a connected one.
+type RegionalService struct {
+	// FailoverThreshold is the number of consecutive retryable failures
+	// in the active region that trigger a failover.
+	FailoverThreshold int
+
+	mu      sync.Mutex
+	regions []Region
+	active  int
+	// failures counts consecutive retryable failures in the active region.
+	failures int
+}
+
+// NewRegionalService creates a RegionalService over regions, starting in
+// the first one. It returns an error if regions is empty.
+func NewRegionalService(regions ...Region) (*RegionalService, error) {
+	if len(regions) == 0 {
+		return nil, errors.New("regional service has no regions")
+	}
+	return &RegionalService{
+		FailoverThreshold: defaultFailureThreshold,
+		regions:           append([]Region(nil), regions...),
+	}, nil
+}
+
+// CurrentRegion returns the name of the active region.
+func (s *RegionalService) CurrentRegion() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.regions[s.active].Name
+}
+
+// Connect connects every region, not just the active one, and fails if
+// any of them does.
+func (s *RegionalService) Connect(ctx context.Context) error {
+	var errs []error
+	for i, r := range s.regions {
+		err := r.Service.Connect(ctx)
+		s.report(i, err)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("region %s: %w", r.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *RegionalService) Ping(ctx context.Context) error {
+	return s.do(func(svc ExternalService) error { return svc.Ping(ctx) })
+}
+
+func (s *RegionalService) GetData(ctx context.Context, key string) (value string, err error) {
+	err = s.do(func(svc ExternalService) error {
+		value, err = svc.GetData(ctx, key)
+		return err
+	})
+	return value, err
+}
+
+func (s *RegionalService) PutData(ctx context.Context, key string, value string) error {
+	return s.do(func(svc ExternalService) error { return svc.PutData(ctx, key, value) })
+}
+
+func (s *RegionalService) DeleteData(ctx context.Context, key string) error {
+	return s.do(func(svc ExternalService) error { return svc.DeleteData(ctx, key) })
+}
+
+func (s *RegionalService) ListKeys(ctx context.Context) (keys []string, err error) {
+	err = s.do(func(svc ExternalService) error {
+		keys, err = svc.ListKeys(ctx)
+		return err
+	})
+	return keys, err
+}
+
+// do calls fn on the active region and records the outcome.
+func (s *RegionalService) do(fn func(ExternalService) error) error {
+	s.mu.Lock()
+	active := s.active
+	svc := s.regions[active].Service
+	s.mu.Unlock()
+
+	err := fn(svc)
+	s.report(active, err)
+	return err
+}
+
+// report updates the failure count of region i after a call that returned
+// err, failing over once it reaches FailoverThreshold. Outcomes from a
+// region that is no longer active are ignored.
+func (s *RegionalService) report(i int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i != s.active {
+		return
+	}
+	if !IsRetryable(err) {
+		s.failures = 0
+		return
+	}
+	s.failures++
+	if s.failures >= s.FailoverThreshold {
+		s.active = (s.active + 1) % len(s.regions)
+		s.failures = 0
+	}
+}
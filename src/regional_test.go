@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRegionalServiceFailover(t *testing.T) {
+	ctx := context.Background()
+	primary := NewMockService("us-east-1")
+	secondary := NewMockService("eu-west-1")
+	svc, err := NewRegionalService(Region{"us-east-1", primary}, Region{"eu-west-1", secondary})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.FailoverThreshold = 2
+
+	if err := svc.PutData(ctx, "before", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if got := svc.CurrentRegion(); got != "us-east-1" {
+		t.Fatalf("Expected to start in us-east-1, got %s", got)
+	}
+
+	// A non-retryable error does not count towards failover.
+	if _, err := svc.GetData(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+
+	primary.Pause()
+	for i := 0; i < 2; i++ {
+		if err := svc.Ping(ctx); !errors.Is(err, ErrUnavailable) {
+			t.Fatalf("Call %d: expected the paused region to fail, got %v", i, err)
+		}
+	}
+	if got := svc.CurrentRegion(); got != "eu-west-1" {
+		t.Fatalf("Expected failover to eu-west-1, got %s", got)
+	}
+
+	if err := svc.PutData(ctx, "after", "value"); err != nil {
+		t.Fatalf("Expected writes to go to the new region, got %v", err)
+	}
+	primary.Resume()
+	if _, err := secondary.GetData(ctx, "after"); err != nil {
+		t.Errorf("Expected the write in eu-west-1, got %v", err)
+	}
+	if _, err := primary.GetData(ctx, "after"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected no write in us-east-1, got %v", err)
+	}
+	if _, err := svc.GetData(ctx, "before"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected reads to stay in eu-west-1 after the primary recovered, got %v", err)
+	}
+}
+
+func TestRegionalServiceConnectsEveryRegion(t *testing.T) {
+	ctx := context.Background()
+	primary := NewMockService("us-east-1", WithStrictConnect(true))
+	secondary := NewMockService("eu-west-1", WithStrictConnect(true))
+	regions := []Region{{"us-east-1", primary}, {"eu-west-1", secondary}}
+	svc, err := NewRegionalService(regions...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.FailoverThreshold = 1
+	regions[0] = Region{"replaced", NewMockService("replaced")}
+
+	if err := svc.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	primary.Pause()
+	if err := svc.Ping(ctx); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Expected the paused region to fail, got %v", err)
+	}
+	if got := svc.CurrentRegion(); got != "eu-west-1" {
+		t.Fatalf("Expected failover to eu-west-1, got %s", got)
+	}
+	if err := svc.PutData(ctx, "key", "value"); err != nil {
+		t.Errorf("Expected the new region to be connected, got %v", err)
+	}
+}
+
+func TestNewRegionalServiceNoRegions(t *testing.T) {
+	if _, err := NewRegionalService(); err == nil {
+		t.Error("Expected an error without regions")
+	}
+}
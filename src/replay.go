@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CallRecord is one recorded operation, written as a line of JSON.
+type CallRecord struct {
+	Op       string        `json:"op"`
+	Key      string        `json:"key,omitempty"`
+	Value    string        `json:"value,omitempty"`
+	Offset   time.Duration `json:"offset"`
+	Duration time.Duration `json:"duration"`
+	TraceID  string        `json:"trace_id,omitempty"`
+	Error    string        `json:"error,omitempty"`
+
+	// Writes holds the writes applied by a transaction's Commit, in order.
+	Writes []WriteRecord `json:"writes,omitempty"`
+}
+
+// WriteRecord is one write applied by a committed transaction. A nil
+// Value marks a delete.
+type WriteRecord struct {
+	Key   string  `json:"key"`
+	Value *string `json:"value,omitempty"`
+}
+
+// recorder serializes CallRecords to a writer.
+type recorder struct {
+	mu    sync.Mutex
+	enc   *json.Encoder
+	start time.Time
+}
+
+// StartRecording writes a CallRecord for every subsequent operation to w,
+// one JSON object per line, until StopRecording is called. The output can
+// be fed to Replay.
+func (m *MockService) StartRecording(w io.Writer) {
+	rec := &recorder{enc: json.NewEncoder(w), start: m.now()}
+	m.mu.Lock()
+	m.recorder = rec
+	m.mu.Unlock()
+}
+
+// StopRecording stops writing CallRecords.
+func (m *MockService) StopRecording() {
+	m.mu.Lock()
+	m.recorder = nil
+	m.mu.Unlock()
+}
+
+// record writes a CallRecord if recording is enabled.
+func (m *MockService) record(ctx context.Context, op *operation, dur time.Duration, err error) {
+	m.mu.Lock()
+	rec := m.recorder
+	m.mu.Unlock()
+	if rec == nil {
+		return
+	}
+
+	cr := CallRecord{
+		Op:       op.name,
+		Key:      op.key,
+		Value:    op.value,
+		Offset:   op.start.Sub(rec.start),
+		Duration: dur,
+		Writes:   op.writes,
+	}
+	cr.TraceID, _ = TraceIDFromContext(ctx)
+	if err != nil {
+		cr.Error = err.Error()
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.enc.Encode(cr)
+}
+
+// Replay re-executes the operations recorded by StartRecording against svc
+// as fast as possible. Operations that failed when recorded are skipped,
+// since a simulated failure cannot be reproduced; any other operation that
+// fails during replay aborts it. Writes outside ExternalService, such as
+// PutDataWithTTL or a transaction's Commit, are replayed as the PutData and
+// DeleteData calls they amount to. An unknown operation aborts the replay.
+func Replay(ctx context.Context, r io.Reader, svc ExternalService) error {
+	return replay(ctx, r, svc, false)
+}
+
+// ReplayTimed is like Replay but preserves the relative timing between
+// operations as they were recorded.
+func ReplayTimed(ctx context.Context, r io.Reader, svc ExternalService) error {
+	return replay(ctx, r, svc, true)
+}
+
+func replay(ctx context.Context, r io.Reader, svc ExternalService, timed bool) error {
+	start := time.Now()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var cr CallRecord
+		if err := json.Unmarshal(scanner.Bytes(), &cr); err != nil {
+			return fmt.Errorf("invalid record on line %d: %w", line, err)
+		}
+		if cr.Error != "" {
+			continue
+		}
+
+		if timed {
+			if wait := cr.Offset - time.Since(start); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return ctx.Err()
+				}
+			}
+		}
+
+		if err := replayCall(ctx, svc, cr); err != nil {
+			return fmt.Errorf("replaying %s on line %d: %w", cr.Op, line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func replayCall(ctx context.Context, svc ExternalService, cr CallRecord) error {
+	if cr.TraceID != "" {
+		ctx = ContextWithTraceID(ctx, cr.TraceID)
+	}
+	switch cr.Op {
+	case "Connect":
+		return svc.Connect(ctx)
+	case "Ping":
+		return svc.Ping(ctx)
+	case "GetData":
+		_, err := svc.GetData(ctx, cr.Key)
+		return err
+	case "PutData", "PutDataBytes", "PutDataWithTTL", "PutDataChecksummed", "PutDataStream":
+		return svc.PutData(ctx, cr.Key, cr.Value)
+	case "DeleteData":
+		return svc.DeleteData(ctx, cr.Key)
+	case "DeletePrefix":
+		return replayDeletePrefix(ctx, svc, cr.Key)
+	case "Commit":
+		return replayCommit(ctx, svc, cr.Writes)
+	case "ListKeys":
+		_, err := svc.ListKeys(ctx)
+		return err
+	case "GetDataBytes", "GetDataVerified", "GetDataWithMetadata", "GetDataStream",
+		"Exists", "GetMulti", "ListKeysPaged", "ListKeysMaybeTruncated",
+		"ListKeysStream", "QueryKeys", "Begin":
+		// Reads outside ExternalService cannot be replayed generically
+		// and do not change state.
+		return nil
+	default:
+		return fmt.Errorf("unknown operation %q", cr.Op)
+	}
+}
+
+// replayDeletePrefix deletes every key starting with prefix, in one call
+// if svc supports DeletePrefix.
+func replayDeletePrefix(ctx context.Context, svc ExternalService, prefix string) error {
+	if d, ok := svc.(interface {
+		DeletePrefix(ctx context.Context, prefix string) (int, error)
+	}); ok {
+		_, err := d.DeletePrefix(ctx, prefix)
+		return err
+	}
+	keys, err := svc.ListKeys(ctx)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if err := svc.DeleteData(ctx, key); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// replayCommit applies a committed transaction's writes in order. A delete
+// of a missing key succeeds, as it does inside a transaction.
+func replayCommit(ctx context.Context, svc ExternalService, writes []WriteRecord) error {
+	for _, w := range writes {
+		if w.Value != nil {
+			if err := svc.PutData(ctx, w.Key, *w.Value); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := svc.DeleteData(ctx, w.Key); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	ctx := ContextWithTraceID(context.Background(), "replay-trace")
+	original := NewMockService("Original")
+
+	var buf bytes.Buffer
+	original.StartRecording(&buf)
+	steps := []func() error{
+		func() error { return original.PutData(ctx, "a", "1") },
+		func() error { return original.PutData(ctx, "b", "2") },
+		func() error { _, err := original.GetData(ctx, "a"); return err },
+		func() error { return original.DeleteData(ctx, "a") },
+		func() error { return original.PutData(ctx, "c", "3") },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Step %d failed: %v", i, err)
+		}
+	}
+	// A failed operation is recorded but must be skipped on replay.
+	if _, err := original.GetData(ctx, "a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	original.StopRecording()
+	original.PutData(ctx, "unrecorded", "x")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("Expected 6 records, got %d:\n%s", len(lines), buf.String())
+	}
+	var first CallRecord
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("Invalid record: %v", err)
+	}
+	if first.Op != "PutData" || first.Key != "a" || first.Value != "1" || first.TraceID != "replay-trace" {
+		t.Errorf("Unexpected first record: %+v", first)
+	}
+
+	replayed := NewMockService("Replayed")
+	if err := Replay(context.Background(), &buf, replayed); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+
+	want := map[string]string{"b": "2", "c": "3"}
+	keys, _ := replayed.ListKeys(context.Background())
+	if len(keys) != len(want) {
+		t.Fatalf("Expected keys %v, got %v", want, keys)
+	}
+	for k, v := range want {
+		if got, err := replayed.GetData(context.Background(), k); err != nil || got != v {
+			t.Errorf("Key %s: expected %q, got %q, %v", k, v, got, err)
+		}
+	}
+}
+
+func TestReplayTimedPreservesTiming(t *testing.T) {
+	records := []CallRecord{
+		{Op: "PutData", Key: "a", Value: "1", Offset: 0},
+		{Op: "PutData", Key: "b", Value: "2", Offset: 50 * time.Millisecond},
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, cr := range records {
+		enc.Encode(cr)
+	}
+
+	start := time.Now()
+	if err := ReplayTimed(context.Background(), &buf, NewMockService("Timed")); err != nil {
+		t.Fatalf("ReplayTimed failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Timed replay took %v, expected at least 50ms", elapsed)
+	}
+}
+
+func TestReplayExtendedWrites(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+
+	var buf bytes.Buffer
+	db.StartRecording(&buf)
+	steps := []func() error{
+		func() error { return db.PutDataBytes(ctx, "bytes", []byte("b")) },
+		func() error { return db.PutDataWithTTL(ctx, "ttl", "t", time.Hour) },
+		func() error { return db.PutDataChecksummed(ctx, "sum", "s") },
+		func() error { return db.PutDataStream(ctx, "stream", strings.NewReader("x")) },
+		func() error { return db.PutData(ctx, "tmp/a", "1") },
+		func() error { return db.PutData(ctx, "tmp/b", "2") },
+		func() error { _, err := db.DeletePrefix(ctx, "tmp/"); return err },
+		func() error {
+			tx, err := db.Begin(ctx)
+			if err != nil {
+				return err
+			}
+			tx.PutData(ctx, "tx", "committed")
+			tx.DeleteData(ctx, "bytes")
+			return tx.Commit(ctx)
+		},
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Step %d failed: %v", i, err)
+		}
+	}
+	db.StopRecording()
+
+	replayed := NewMockService("Replayed")
+	if err := Replay(ctx, &buf, replayed); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	want, err := db.ListKeys(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := replayed.ListKeys(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("Expected keys %v after replay, got %v", want, got)
+	}
+	for _, k := range want {
+		v, _ := db.GetData(ctx, k)
+		if r, err := replayed.GetData(ctx, k); err != nil || r != v {
+			t.Errorf("Key %s: expected %q, got %q, %v", k, v, r, err)
+		}
+	}
+}
+
+func TestReplayUnknownOperation(t *testing.T) {
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(CallRecord{Op: "Frobnicate", Key: "a"})
+	err := Replay(context.Background(), &buf, NewMockService("Replayed"))
+	if err == nil || !strings.Contains(err.Error(), "Frobnicate") {
+		t.Errorf("Expected an unknown operation to abort the replay, got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+)
+
+// ShardedService is an ExternalService that partitions keys across shards.
+// Each key is owned by exactly one shard, chosen by a hash of the key;
+// operations that are not about a single key fan out to every shard.
+type ShardedService struct {
+	shards []ExternalService
+	hash   func(key string) uint32
+}
+
+// NewShardedService creates a ShardedService routing each key to
+// shards[hash(key) % len(shards)]. A nil hash uses 32-bit FNV-1a. It
+// returns an error if shards is empty.
+func NewShardedService(shards []ExternalService, hash func(key string) uint32) (*ShardedService, error) {
+	if len(shards) == 0 {
+		return nil, errors.New("sharded service has no shards")
+	}
+	if hash == nil {
+		hash = fnvHash
+	}
+	return &ShardedService{shards: append([]ExternalService(nil), shards...), hash: hash}, nil
+}
+
+func fnvHash(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32()
+}
+
+// Shard returns the index of the shard that owns key.
+func (s *ShardedService) Shard(key string) int {
+	return int(s.hash(key) % uint32(len(s.shards)))
+}
+
+// Connect connects every shard, reporting each shard that failed.
+func (s *ShardedService) Connect(ctx context.Context) error {
+	return s.each(func(svc ExternalService) error { return svc.Connect(ctx) })
+}
+
+// Ping pings every shard, reporting each shard that failed.
+func (s *ShardedService) Ping(ctx context.Context) error {
+	return s.each(func(svc ExternalService) error { return svc.Ping(ctx) })
+}
+
+func (s *ShardedService) GetData(ctx context.Context, key string) (string, error) {
+	return s.shards[s.Shard(key)].GetData(ctx, key)
+}
+
+func (s *ShardedService) PutData(ctx context.Context, key string, value string) error {
+	return s.shards[s.Shard(key)].PutData(ctx, key, value)
+}
+
+func (s *ShardedService) DeleteData(ctx context.Context, key string) error {
+	return s.shards[s.Shard(key)].DeleteData(ctx, key)
+}
+
+// ListKeys returns the sorted union of the keys of every shard. It fails
+// if any shard fails.
+func (s *ShardedService) ListKeys(ctx context.Context) ([]string, error) {
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	err := s.each(func(svc ExternalService) error {
+		keys, err := svc.ListKeys(ctx)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, k := range keys {
+			seen[k] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// each calls fn for every shard concurrently and joins the errors of the
+// shards that failed.
+func (s *ShardedService) each(fn func(ExternalService) error) error {
+	errs := make([]error, len(s.shards))
+	var wg sync.WaitGroup
+	for i, svc := range s.shards {
+		i, svc := i, svc
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(svc); err != nil {
+				errs[i] = fmt.Errorf("shard %d: %w", i, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestShardedServiceRouting(t *testing.T) {
+	ctx := context.Background()
+	shards := []*MockService{
+		NewMockService("Shard0", WithDryRun(true)),
+		NewMockService("Shard1", WithDryRun(true)),
+		NewMockService("Shard2", WithDryRun(true)),
+	}
+	// Route by the key's first letter so placement is easy to predict.
+	hash := func(key string) uint32 { return uint32(key[0] - 'a') }
+	svc, err := NewShardedService([]ExternalService{shards[0], shards[1], shards[2]}, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	placement := map[string]int{"apple": 0, "banana": 1, "cherry": 2, "date": 0, "elder": 1}
+	for key := range placement {
+		if err := svc.PutData(ctx, key, strings.ToUpper(key)); err != nil {
+			t.Fatalf("PutData %s failed: %v", key, err)
+		}
+	}
+
+	for key, want := range placement {
+		for i, shard := range shards {
+			_, err := shard.GetData(ctx, key)
+			if i == want && err != nil {
+				t.Errorf("Expected %s on shard %d, got %v", key, i, err)
+			}
+			if i != want && !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected %s only on shard %d, but shard %d returned %v", key, want, i, err)
+			}
+		}
+		if got, err := svc.GetData(ctx, key); err != nil || got != strings.ToUpper(key) {
+			t.Errorf("GetData(%s) = %q, %v", key, got, err)
+		}
+	}
+
+	keys, err := svc.ListKeys(ctx)
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+	if want := []string{"apple", "banana", "cherry", "date", "elder"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Expected the union %v, got %v", want, keys)
+	}
+
+	if err := svc.DeleteData(ctx, "banana"); err != nil {
+		t.Fatalf("DeleteData failed: %v", err)
+	}
+	if _, err := shards[1].GetData(ctx, "banana"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected banana to be deleted from shard 1, got %v", err)
+	}
+}
+
+func TestShardedServiceDefaultHash(t *testing.T) {
+	ctx := context.Background()
+	a := NewMockService("A", WithDryRun(true))
+	b := NewMockService("B", WithDryRun(true))
+	svc, err := NewShardedService([]ExternalService{a, b}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"k1", "k2", "k3", "k4", "k5", "k6"} {
+		if err := svc.PutData(ctx, key, "v"); err != nil {
+			t.Fatalf("PutData failed: %v", err)
+		}
+	}
+	aKeys, _ := a.ListKeys(ctx)
+	bKeys, _ := b.ListKeys(ctx)
+	if len(aKeys)+len(bKeys) != 6 {
+		t.Errorf("Expected every key on exactly one shard, got %v and %v", aKeys, bKeys)
+	}
+}
+
+func TestShardedServiceFanOutErrors(t *testing.T) {
+	ctx := context.Background()
+	healthy := NewMockService("Healthy", WithDryRun(true))
+	broken := NewMockService("Broken", WithFailureRate(1.0))
+	svc, err := NewShardedService([]ExternalService{healthy, broken}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.Ping(ctx); err == nil || !strings.Contains(err.Error(), "shard 1") {
+		t.Errorf("Expected Ping to report shard 1, got %v", err)
+	}
+	if _, err := svc.ListKeys(ctx); !errors.Is(err, ErrTransient) {
+		t.Errorf("Expected ListKeys to fail with the shard's error, got %v", err)
+	}
+}
+
+func TestNewShardedServiceNoShards(t *testing.T) {
+	if _, err := NewShardedService(nil, nil); err == nil {
+		t.Error("Expected an error without shards")
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sloKey is the key AssertLatencySLO reads and writes.
+const sloKey = "slo-key"
+
+// AssertLatencySLO calls op on svc samples times and fails t if the p-th
+// percentile (0-100) latency of the successful calls exceeds threshold.
+// Failed calls are left out of the percentile but their rate is reported.
+// op is one of Connect, Ping, GetData, PutData, DeleteData or ListKeys.
+func AssertLatencySLO(t testing.TB, svc ExternalService, op string, samples int, p float64, threshold time.Duration) {
+	t.Helper()
+	ctx := context.Background()
+
+	call, err := sloOperation(svc, op)
+	if err != nil {
+		t.Fatalf("AssertLatencySLO: %v", err)
+	}
+	if op == "GetData" || op == "DeleteData" {
+		// Best effort: a failed setup write just shows up as failed samples.
+		_ = svc.PutData(ctx, sloKey, "value")
+	}
+
+	var latencies []time.Duration
+	failures := 0
+	for i := 0; i < samples; i++ {
+		start := time.Now()
+		if err := call(ctx); err != nil {
+			failures++
+			continue
+		}
+		latencies = append(latencies, time.Since(start))
+		if op == "DeleteData" {
+			_ = svc.PutData(ctx, sloKey, "value")
+		}
+	}
+
+	failureRate := float64(failures) / float64(samples)
+	if len(latencies) == 0 {
+		t.Errorf("%s SLO: all %d calls failed", op, samples)
+		return
+	}
+	got := latencyPercentile(latencies, p)
+	if got > threshold {
+		t.Errorf("%s SLO violated: p%v latency %v exceeds %v (%d samples, %.1f%% failed)",
+			op, p, got, threshold, samples, failureRate*100)
+		return
+	}
+	t.Logf("%s SLO met: p%v latency %v within %v (%d samples, %.1f%% failed)",
+		op, p, got, threshold, samples, failureRate*100)
+}
+
+// AssertFailureBudget fails t if more than budget (a fraction in [0, 1])
+// of the calls src has recorded failed, retried attempts included.
+func AssertFailureBudget(t testing.TB, src MetricsSource, budget float64) {
+	t.Helper()
+	if err := CheckFailureBudget(src, budget); err != nil {
+		t.Errorf("Failure budget exceeded: %v", err)
+	}
+}
+
+// sloOperation returns a function performing op against svc.
+func sloOperation(svc ExternalService, op string) (func(context.Context) error, error) {
+	switch op {
+	case "Connect":
+		return svc.Connect, nil
+	case "Ping":
+		return svc.Ping, nil
+	case "GetData":
+		return func(ctx context.Context) error {
+			_, err := svc.GetData(ctx, sloKey)
+			return err
+		}, nil
+	case "PutData":
+		return func(ctx context.Context) error { return svc.PutData(ctx, sloKey, "value") }, nil
+	case "DeleteData":
+		return func(ctx context.Context) error { return svc.DeleteData(ctx, sloKey) }, nil
+	case "ListKeys":
+		return func(ctx context.Context) error {
+			_, err := svc.ListKeys(ctx)
+			return err
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown operation %q", op)
+}
+
+// latencyPercentile returns the p-th percentile (0-100) of latencies using
+// the nearest-rank method.
+func latencyPercentile(latencies []time.Duration, p float64) time.Duration {
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// sloTB records the failures reported by AssertLatencySLO so its own tests
+// can check them without failing.
+type sloTB struct {
+	testing.TB
+	errors []string
+}
+
+func (tb *sloTB) Errorf(format string, args ...any) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func (tb *sloTB) Logf(string, ...any) {}
+
+func TestAssertLatencySLO(t *testing.T) {
+	t.Run("fast service meets the SLO", func(t *testing.T) {
+		AssertLatencySLO(t, NewMockService("Fast"), "GetData", 50, 99, 150*time.Millisecond)
+	})
+
+	t.Run("slow service violates the SLO", func(t *testing.T) {
+		tb := &sloTB{TB: t}
+		slow := NewMockService("Slow", WithResponseTime(20*time.Millisecond))
+		AssertLatencySLO(tb, slow, "GetData", 5, 99, 5*time.Millisecond)
+		if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "GetData SLO violated") {
+			t.Errorf("Expected an SLO violation, got %q", tb.errors)
+		}
+	})
+
+	t.Run("failures are excluded and reported", func(t *testing.T) {
+		tb := &sloTB{TB: t}
+		flaky := NewMockService("Flaky",
+			WithResponseTime(10*time.Millisecond),
+			WithFailureRate(0.5),
+			WithSeed(3),
+		)
+		AssertLatencySLO(tb, flaky, "Ping", 20, 99, time.Millisecond)
+		if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "% failed") || strings.Contains(tb.errors[0], " 0.0% failed") {
+			t.Errorf("Expected the violation to report a non-zero failure rate, got %q", tb.errors)
+		}
+	})
+}
+
+func TestAssertFailureBudget(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("retries hide failures from callers but not the budget", func(t *testing.T) {
+		flaky := NewMockService("Flaky", WithFailureRate(0.5), WithSeed(7))
+		svc := Chain(flaky, WithRetry(20, 0))
+		for i := 0; i < 20; i++ {
+			if err := svc.Ping(ctx); err != nil {
+				t.Fatalf("Expected retries to hide every failure, got %v", err)
+			}
+		}
+
+		tb := &sloTB{TB: t}
+		AssertFailureBudget(tb, flaky, 0.1)
+		if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "Flaky failed") {
+			t.Errorf("Expected the budget to be exceeded, got %q", tb.errors)
+		}
+	})
+
+	t.Run("healthy service stays within budget", func(t *testing.T) {
+		healthy := NewMockService("Healthy", WithDryRun(true))
+		for i := 0; i < 10; i++ {
+			_ = healthy.Ping(ctx)
+		}
+		AssertFailureBudget(t, healthy, 0)
+	})
+
+	t.Run("no calls", func(t *testing.T) {
+		AssertFailureBudget(t, NewMockService("Idle"), 0)
+	})
+}
+
+func TestLatencyPercentile(t *testing.T) {
+	latencies := make([]time.Duration, 100)
+	for i := range latencies {
+		latencies[len(latencies)-1-i] = time.Duration(i+1) * time.Millisecond
+	}
+	for _, tt := range []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0, time.Millisecond},
+		{50, 50 * time.Millisecond},
+		{99, 99 * time.Millisecond},
+		{100, 100 * time.Millisecond},
+	} {
+		if got := latencyPercentile(latencies, tt.p); got != tt.want {
+			t.Errorf("latencyPercentile(p%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
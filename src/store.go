@@ -0,0 +1,70 @@
+package main
+
+import "sync"
+
+// Store is the storage backend behind a MockService. Keeping it separate
+// lets the latency and failure simulation run on top of any backend, such
+// as a file- or bbolt-backed store.
+type Store interface {
+	// Get returns the value for key, or ErrNotFound if it does not exist.
+	Get(key string) (string, error)
+	// Set stores value under key, replacing any existing value.
+	Set(key, value string) error
+	// Delete removes key, returning ErrNotFound if it does not exist.
+	Delete(key string) error
+	// Keys returns every key in the store, in any order.
+	Keys() ([]string, error)
+}
+
+// MemoryStore is an in-memory Store. It is safe for concurrent use and can
+// be shared between several services.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	data map[string]string
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: make(map[string]string)}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(key string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	val, ok := s.data[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return val, nil
+}
+
+// Set implements Store.
+func (s *MemoryStore) Set(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = value
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data[key]; !ok {
+		return ErrNotFound
+	}
+	delete(s.data, key)
+	return nil
+}
+
+// Keys implements Store.
+func (s *MemoryStore) Keys() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]string, 0, len(s.data))
+	for k := range s.data {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// recordingStore is a Store stub that records the calls routed to it.
+type recordingStore struct {
+	*MemoryStore
+	calls []string
+}
+
+func (s *recordingStore) Get(key string) (string, error) {
+	s.calls = append(s.calls, "Get "+key)
+	return s.MemoryStore.Get(key)
+}
+
+func (s *recordingStore) Set(key, value string) error {
+	s.calls = append(s.calls, "Set "+key)
+	return s.MemoryStore.Set(key, value)
+}
+
+func (s *recordingStore) Delete(key string) error {
+	s.calls = append(s.calls, "Delete "+key)
+	return s.MemoryStore.Delete(key)
+}
+
+func (s *recordingStore) Keys() ([]string, error) {
+	s.calls = append(s.calls, "Keys")
+	return s.MemoryStore.Keys()
+}
+
+func TestMockServiceRoutesThroughStore(t *testing.T) {
+	ctx := context.Background()
+	store := &recordingStore{MemoryStore: NewMemoryStore()}
+	svc := NewMockService("Routed", WithStore(store), WithNamespace("ns"))
+
+	if err := svc.PutData(ctx, "key", "value"); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if got, err := svc.GetData(ctx, "key"); err != nil || got != "value" {
+		t.Fatalf("Get returned %q, %v", got, err)
+	}
+	if keys, err := svc.ListKeys(ctx); err != nil || len(keys) != 1 || keys[0] != "key" {
+		t.Fatalf("ListKeys returned %v, %v", keys, err)
+	}
+	if err := svc.DeleteData(ctx, "key"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	want := []string{"Set ns:key", "Get ns:key", "Keys", "Delete ns:key"}
+	if len(store.calls) != len(want) {
+		t.Fatalf("Expected calls %v, got %v", want, store.calls)
+	}
+	for i := range want {
+		if store.calls[i] != want[i] {
+			t.Errorf("Call %d: expected %q, got %q", i, want[i], store.calls[i])
+		}
+	}
+}
+
+// failingStore is a Store whose every call fails.
+type failingStore struct{}
+
+var errDiskFull = errors.New("disk full")
+
+func (failingStore) Get(string) (string, error) { return "", errDiskFull }
+func (failingStore) Set(string, string) error   { return errDiskFull }
+func (failingStore) Delete(string) error        { return errDiskFull }
+func (failingStore) Keys() ([]string, error)    { return nil, errDiskFull }
+
+func TestMockServiceSurfacesStoreErrors(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Broken", WithStore(failingStore{}))
+
+	if err := svc.PutData(ctx, "key", "value"); !errors.Is(err, errDiskFull) {
+		t.Errorf("Expected store error from Put, got %v", err)
+	}
+	if _, err := svc.GetData(ctx, "key"); !errors.Is(err, errDiskFull) {
+		t.Errorf("Expected store error from Get, got %v", err)
+	}
+	if _, err := svc.ListKeys(ctx); !errors.Is(err, errDiskFull) {
+		t.Errorf("Expected store error from ListKeys, got %v", err)
+	}
+}
+
+// reversedStore lists its keys in reverse order, like a backend with no
+// ordering guarantee.
+type reversedStore struct {
+	*MemoryStore
+}
+
+func (s reversedStore) Keys() ([]string, error) {
+	keys, err := s.MemoryStore.Keys()
+	sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+	return keys, err
+}
+
+func TestListKeysSorted(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Unordered", WithStore(reversedStore{NewMemoryStore()}))
+	for _, key := range []string{"m", "b", "z", "a/2", "a/10", "B"} {
+		if err := svc.PutData(ctx, key, "value"); err != nil {
+			t.Fatalf("PutData failed: %v", err)
+		}
+	}
+
+	want := []string{"B", "a/10", "a/2", "b", "m", "z"}
+	keys, err := svc.ListKeys(ctx)
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("Expected keys %v, got %v", want, keys)
+	}
+	paged, _, err := svc.ListKeysPaged(ctx, len(want), "")
+	if err != nil || !reflect.DeepEqual(paged, want) {
+		t.Errorf("Expected paged keys %v, got %v, %v", want, paged, err)
+	}
+}
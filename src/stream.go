@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// streamChunkSize caps how many bytes a single Read of a value stream
+// returns, so that bandwidth pacing and cancellation apply while the value
+// is still downloading.
+const streamChunkSize = 32 * 1024
+
+// GetDataStream retrieves the value of key as a stream, for code that
+// consumes readers rather than whole strings. Opening the stream costs a
+// round trip like GetData; the bytes are then paced at BytesPerSecond as
+// they are read. Reads fail with ctx's error once ctx is done.
+func (m *MockService) GetDataStream(ctx context.Context, key string) (_ io.ReadCloser, err error) {
+	defer m.observe(ctx, m.startOp("GetDataStream", key), &err)
+	release, err := m.begin(ctx, m.warmupLatency(m.responseTime))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if m.shouldFail() {
+		return nil, fmt.Errorf("failed to get data from %s: %w", m.name, ErrTransient)
+	}
+	val, err := m.readLocked(key, m.shouldCorrupt(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if m.ResponseTemplate {
+		if val, err = m.render(key, val); err != nil {
+			return nil, err
+		}
+	}
+	return &valueStream{ctx: ctx, m: m, val: val}, nil
+}
+
+// valueStream reads a value fetched by GetDataStream.
+type valueStream struct {
+	ctx    context.Context
+	m      *MockService
+	val    string
+	off    int
+	closed bool
+}
+
+func (s *valueStream) Read(p []byte) (int, error) {
+	if s.closed {
+		return 0, errors.New("read from closed stream")
+	}
+	if err := s.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if s.off >= len(s.val) {
+		return 0, io.EOF
+	}
+	n := min(len(p), len(s.val)-s.off, streamChunkSize)
+	if err := s.m.sleep(s.ctx, s.m.transferTime(n)); err != nil {
+		return 0, err
+	}
+	copy(p, s.val[s.off:s.off+n])
+	s.off += n
+	return n, nil
+}
+
+func (s *valueStream) Close() error {
+	s.closed = true
+	return nil
+}
+
+// PutDataStream stores everything read from r under key, for code that
+// produces uploads as streams. It reads r in chunks, failing with
+// ErrValueTooLarge as soon as more than MaxValueSize bytes arrive rather
+// than buffering the whole payload, and stops with ctx's error between
+// chunks once ctx is done.
+func (m *MockService) PutDataStream(ctx context.Context, key string, r io.Reader) (err error) {
+	op := m.startOp("PutDataStream", key)
+	defer m.observe(ctx, op, &err)
+	if err := m.validateKey(key); err != nil {
+		return err
+	}
+	value, err := m.readUpload(ctx, key, r)
+	if err != nil {
+		return err
+	}
+	op.withValue(value)
+	return m.put(ctx, key, value, nil)
+}
+
+// readUpload reads the value for key from r, enforcing MaxValueSize.
+func (m *MockService) readUpload(ctx context.Context, key string, r io.Reader) (string, error) {
+	var b strings.Builder
+	buf := make([]byte, streamChunkSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		n, err := r.Read(buf)
+		b.Write(buf[:n])
+		if m.MaxValueSize > 0 && b.Len() > m.MaxValueSize {
+			return "", fmt.Errorf("value for key %s exceeds %d byte limit: %w", key, m.MaxValueSize, ErrValueTooLarge)
+		}
+		if errors.Is(err, io.EOF) {
+			return b.String(), nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read value for key %s: %w", key, err)
+		}
+	}
+}
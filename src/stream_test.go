@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetDataStream(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("Objects", WithDryRun(true))
+	value := strings.Repeat("0123456789", 10000)
+	if err := svc.PutData(ctx, "large", value); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+
+	r, err := svc.GetDataStream(ctx, "large")
+	if err != nil {
+		t.Fatalf("GetDataStream failed: %v", err)
+	}
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Reading the stream failed: %v", err)
+	}
+	if string(got) != value {
+		t.Errorf("Expected the streamed value to match, got %d bytes", len(got))
+	}
+
+	if _, err := svc.GetDataStream(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing key, got %v", err)
+	}
+	if got := svc.Metrics()["GetDataStream"]; got.Count != 2 || got.Failures != 1 {
+		t.Errorf("Expected 2 GetDataStream calls with 1 failure, got %+v", got)
+	}
+}
+
+func TestGetDataStreamCancel(t *testing.T) {
+	clock := NewFakeClock(clockEpoch)
+	svc := NewMockService("Objects", WithClock(clock), WithBandwidth(4))
+	if err := svc.store.Set("slow", "abcdefgh"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r, err := svc.GetDataStream(ctx, "slow")
+	if err != nil {
+		t.Fatalf("GetDataStream failed: %v", err)
+	}
+	defer r.Close()
+
+	buf := make([]byte, 4)
+	read := make(chan error, 1)
+	go func() {
+		_, err := r.Read(buf)
+		read <- err
+	}()
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Second)
+	if err := <-read; err != nil {
+		t.Fatalf("First read failed: %v", err)
+	}
+	if string(buf) != "abcd" {
+		t.Errorf("Expected the first chunk to be %q, got %q", "abcd", buf)
+	}
+
+	// Cancel while the second chunk is still downloading.
+	go func() {
+		_, err := r.Read(buf)
+		read <- err
+	}()
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-read; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the read to stop with context.Canceled, got %v", err)
+	}
+	if _, err := r.Read(buf); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected later reads to fail too, got %v", err)
+	}
+}
+
+// countingReader yields n bytes of 'x' and reports how many it produced.
+type countingReader struct {
+	n, read int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	if r.read >= r.n {
+		return 0, io.EOF
+	}
+	k := min(len(p), r.n-r.read)
+	for i := range p[:k] {
+		p[i] = 'x'
+	}
+	r.read += k
+	return k, nil
+}
+
+func TestPutDataStream(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stores the stream", func(t *testing.T) {
+		svc := NewMockService("Uploads", WithDryRun(true))
+		value := strings.Repeat("payload-", 20000)
+		if err := svc.PutDataStream(ctx, "upload", strings.NewReader(value)); err != nil {
+			t.Fatalf("PutDataStream failed: %v", err)
+		}
+		if got, err := svc.GetData(ctx, "upload"); err != nil || got != value {
+			t.Errorf("Expected the uploaded value back, got %d bytes, %v", len(got), err)
+		}
+		if _, md, err := svc.GetDataWithMetadata(ctx, "upload"); err != nil || md.Size != len(value) {
+			t.Errorf("Expected metadata for %d bytes, got %+v, %v", len(value), md, err)
+		}
+	})
+
+	t.Run("stops at the size limit", func(t *testing.T) {
+		svc := NewMockService("Uploads", WithDryRun(true), WithMaxValueSize(64*1024))
+		r := &countingReader{n: 10 << 20}
+		err := svc.PutDataStream(ctx, "huge", r)
+		if !errors.Is(err, ErrValueTooLarge) {
+			t.Fatalf("Expected ErrValueTooLarge, got %v", err)
+		}
+		if r.read > 64*1024+streamChunkSize {
+			t.Errorf("Expected reading to stop soon after the limit, read %d bytes", r.read)
+		}
+		if ok, _ := svc.Exists(ctx, "huge"); ok {
+			t.Error("Expected the oversized value not to be stored")
+		}
+	})
+
+	t.Run("canceled read", func(t *testing.T) {
+		svc := NewMockService("Uploads", WithDryRun(true))
+		ctx, cancel := context.WithCancel(ctx)
+		pr, pw := io.Pipe()
+		done := make(chan error, 1)
+		go func() { done <- svc.PutDataStream(ctx, "partial", pr) }()
+
+		// The first chunk arrives, then the upload is canceled.
+		if _, err := pw.Write([]byte("first chunk")); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		cancel()
+		go pw.Write([]byte("second chunk"))
+
+		select {
+		case err := <-done:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("Expected context.Canceled, got %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("PutDataStream did not stop after cancellation")
+		}
+		pw.Close()
+		if ok, _ := svc.Exists(context.Background(), "partial"); ok {
+			t.Error("Expected a canceled upload not to be stored")
+		}
+	})
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// stressKeys is the size of the key space StressTest spreads its
+// operations over.
+const stressKeys = 100
+
+// StressResult summarizes a StressTest run.
+type StressResult struct {
+	Total     int64
+	Successes int64
+	Failures  int64
+	Duration  time.Duration
+	OpsPerSec float64
+	// Ops counts the calls made to each operation.
+	Ops map[string]int64
+}
+
+// WorkloadMix weights the operations a stress run performs. Weights are
+// relative: {"GetData": 8, "PutData": 2} makes 80% of calls reads. The
+// supported operations are Ping, GetData, PutData, DeleteData and
+// ListKeys.
+type WorkloadMix map[string]float64
+
+// defaultMix is an even split of reads and writes.
+var defaultMix = WorkloadMix{"GetData": 1, "PutData": 1}
+
+// stressOps are the operations a WorkloadMix may weight.
+var stressOps = map[string]func(ctx context.Context, svc ExternalService, key string, rng *rand.Rand) error{
+	"Ping": func(ctx context.Context, svc ExternalService, _ string, _ *rand.Rand) error {
+		return svc.Ping(ctx)
+	},
+	"GetData": func(ctx context.Context, svc ExternalService, key string, _ *rand.Rand) error {
+		_, err := svc.GetData(ctx, key)
+		return err
+	},
+	"PutData": func(ctx context.Context, svc ExternalService, key string, rng *rand.Rand) error {
+		return svc.PutData(ctx, key, fmt.Sprintf("value-%d", rng.Int()))
+	},
+	"DeleteData": func(ctx context.Context, svc ExternalService, key string, _ *rand.Rand) error {
+		return svc.DeleteData(ctx, key)
+	},
+	"ListKeys": func(ctx context.Context, svc ExternalService, _ string, _ *rand.Rand) error {
+		_, err := svc.ListKeys(ctx)
+		return err
+	},
+}
+
+// picker chooses operations at random according to a WorkloadMix.
+type picker struct {
+	ops []string
+	// cumulative[i] is the normalized weight of ops[0..i].
+	cumulative []float64
+}
+
+// newPicker validates mix and normalizes its weights.
+func newPicker(mix WorkloadMix) (*picker, error) {
+	ops := make([]string, 0, len(mix))
+	total := 0.0
+	for op, w := range mix {
+		if _, ok := stressOps[op]; !ok {
+			return nil, fmt.Errorf("unsupported operation %q in workload mix", op)
+		}
+		if w < 0 {
+			return nil, fmt.Errorf("negative weight %v for %s in workload mix", w, op)
+		}
+		ops = append(ops, op)
+		total += w
+	}
+	if total == 0 {
+		return nil, errors.New("workload mix has no positive weights")
+	}
+	sort.Strings(ops)
+
+	p := &picker{ops: ops, cumulative: make([]float64, len(ops))}
+	sum := 0.0
+	for i, op := range ops {
+		sum += mix[op] / total
+		p.cumulative[i] = sum
+	}
+	return p, nil
+}
+
+// pick returns a random operation.
+func (p *picker) pick(rng *rand.Rand) string {
+	r := rng.Float64()
+	for i, c := range p.cumulative {
+		if r < c {
+			return p.ops[i]
+		}
+	}
+	return p.ops[len(p.ops)-1]
+}
+
+// StressTest runs an even mix of random GetData and PutData calls against
+// svc. See StressTestWithMix.
+func StressTest(ctx context.Context, svc ExternalService, workers int, duration time.Duration) StressResult {
+	result, _ := StressTestWithMix(ctx, svc, workers, duration, defaultMix)
+	return result
+}
+
+// StressTestWithMix runs random calls chosen according to mix against svc
+// from workers goroutines for duration, or until ctx is done, and reports
+// the outcome. Reads and deletes of keys that do not exist count as
+// successes, since the service answered correctly. Calls cut short by the
+// end of the run are not counted. It returns an error if mix is invalid.
+func StressTestWithMix(ctx context.Context, svc ExternalService, workers int, duration time.Duration, mix WorkloadMix) (StressResult, error) {
+	p, err := newPicker(mix)
+	if err != nil {
+		return StressResult{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	var mu sync.Mutex
+	result := StressResult{Ops: make(map[string]int64)}
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := 0; w < workers; w++ {
+		rng := rand.New(rand.NewSource(int64(w)))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var successes, failures int64
+			ops := make(map[string]int64)
+			for ctx.Err() == nil {
+				op := p.pick(rng)
+				key := fmt.Sprintf("stress-key-%d", rng.Intn(stressKeys))
+				err := stressOps[op](ctx, svc, key, rng)
+				if ctx.Err() != nil {
+					break
+				}
+				ops[op]++
+				if err != nil && !errors.Is(err, ErrNotFound) {
+					failures++
+				} else {
+					successes++
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			result.Successes += successes
+			result.Failures += failures
+			for op, n := range ops {
+				result.Ops[op] += n
+			}
+		}()
+	}
+	wg.Wait()
+
+	result.Duration = time.Since(start)
+	result.Total = result.Successes + result.Failures
+	if secs := result.Duration.Seconds(); secs > 0 {
+		result.OpsPerSec = float64(result.Total) / secs
+	}
+	return result, nil
+}
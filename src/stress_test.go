@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStressTest(t *testing.T) {
+	svc := NewMockService("Stressed",
+		WithResponseTime(100*time.Microsecond),
+		WithFailureRate(0.2),
+		WithSeed(1),
+	)
+
+	result := StressTest(context.Background(), svc, 8, 50*time.Millisecond)
+	if result.Total == 0 {
+		t.Fatal("Expected the stress test to run some operations")
+	}
+	if result.Successes+result.Failures != result.Total {
+		t.Errorf("Expected successes+failures == total, got %d+%d != %d",
+			result.Successes, result.Failures, result.Total)
+	}
+	if result.Failures == 0 || result.Successes == 0 {
+		t.Errorf("Expected both successes and failures at a 20%% failure rate, got %+v", result)
+	}
+	if result.Duration < 50*time.Millisecond {
+		t.Errorf("Expected the run to last the full duration, took %v", result.Duration)
+	}
+	if want := float64(result.Total) / result.Duration.Seconds(); result.OpsPerSec != want {
+		t.Errorf("Expected %v ops/sec, got %v", want, result.OpsPerSec)
+	}
+}
+
+func TestStressTestHonorsCancellation(t *testing.T) {
+	svc := NewMockService("Stressed", WithResponseTime(time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result := StressTest(ctx, svc, 4, time.Minute)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the stress test to stop when ctx is done, took %v", elapsed)
+	}
+	if result.Successes+result.Failures != result.Total {
+		t.Errorf("Expected successes+failures == total, got %+v", result)
+	}
+}
+
+func TestStressTestWithMix(t *testing.T) {
+	svc := NewMockService("Mixed", WithDryRun(true))
+	mix := WorkloadMix{"GetData": 80, "PutData": 15, "ListKeys": 5}
+
+	result, err := StressTestWithMix(context.Background(), svc, 4, 100*time.Millisecond, mix)
+	if err != nil {
+		t.Fatalf("StressTestWithMix failed: %v", err)
+	}
+	if result.Total < 1000 {
+		t.Fatalf("Expected many samples, got %d", result.Total)
+	}
+
+	var counted int64
+	for _, n := range result.Ops {
+		counted += n
+	}
+	if counted != result.Total {
+		t.Errorf("Expected per-operation counts to sum to %d, got %d", result.Total, counted)
+	}
+	for op, weight := range mix {
+		want := weight / 100
+		got := float64(result.Ops[op]) / float64(result.Total)
+		if got < want-0.05 || got > want+0.05 {
+			t.Errorf("Expected %s to be about %.0f%% of calls, got %.1f%%", op, want*100, got*100)
+		}
+	}
+	if result.Ops["DeleteData"] != 0 {
+		t.Errorf("Expected no unweighted operations, got %d deletes", result.Ops["DeleteData"])
+	}
+}
+
+func TestWorkloadMixValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		mix  WorkloadMix
+	}{
+		{"negative weight", WorkloadMix{"GetData": 1, "PutData": -1}},
+		{"unknown operation", WorkloadMix{"Truncate": 1}},
+		{"all zero", WorkloadMix{"GetData": 0}},
+		{"empty", WorkloadMix{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := StressTestWithMix(context.Background(), NewMockService("Invalid"), 1, time.Millisecond, tt.mix)
+			if err == nil {
+				t.Errorf("Expected an error for %v", tt.mix)
+			}
+		})
+	}
+}
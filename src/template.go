@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// render expands the response template val for a read of key.
+func (m *MockService) render(key, val string) (string, error) {
+	tmpl, err := template.New(key).Funcs(template.FuncMap{
+		"key":       func() string { return key },
+		"timestamp": func() string { return m.now().UTC().Format(time.RFC3339) },
+	}).Parse(val)
+	if err != nil {
+		return "", fmt.Errorf("invalid response template for %s: %v: %w", key, err, ErrPermanent)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, nil); err != nil {
+		return "", fmt.Errorf("failed to render response template for %s: %v: %w", key, err, ErrPermanent)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestResponseTemplate(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC))
+	svc := NewMockService("API", WithDryRun(true), WithClock(clock), WithResponseTemplate(true))
+
+	tmpl := `{"id":"{{key}}","served_at":"{{timestamp}}"}`
+	if err := svc.PutData(ctx, "user-7", tmpl); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+
+	got, err := svc.GetData(ctx, "user-7")
+	if err != nil {
+		t.Fatalf("GetData failed: %v", err)
+	}
+	if want := `{"id":"user-7","served_at":"2024-03-01T12:30:00Z"}`; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	clock.Advance(time.Minute)
+	got, _ = svc.GetData(ctx, "user-7")
+	if want := `{"id":"user-7","served_at":"2024-03-01T12:31:00Z"}`; got != want {
+		t.Errorf("Expected the timestamp to follow the clock, got %s", got)
+	}
+
+	// The stored value stays a template.
+	if raw, _ := svc.store.Get("user-7"); raw != tmpl {
+		t.Errorf("Expected the stored template to be unchanged, got %s", raw)
+	}
+
+	if err := svc.PutData(ctx, "broken", "{{unknown}}"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	if _, err := svc.GetData(ctx, "broken"); !errors.Is(err, ErrPermanent) {
+		t.Errorf("Expected a permanent error for an invalid template, got %v", err)
+	}
+}
+
+func TestResponseTemplateDisabled(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMockService("API", WithDryRun(true))
+	if err := svc.PutData(ctx, "key", "{{key}}"); err != nil {
+		t.Fatalf("PutData failed: %v", err)
+	}
+	if got, err := svc.GetData(ctx, "key"); err != nil || got != "{{key}}" {
+		t.Errorf("Expected the literal value without ResponseTemplate, got %q, %v", got, err)
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+// Tracer starts spans that time individual operations. It is a minimal
+// stand-in for a tracing SDK such as OpenTelemetry.
+type Tracer interface {
+	StartSpan(name string) Span
+}
+
+// Span is an in-progress timed operation. End must be called exactly once.
+type Span interface {
+	End()
+}
+
+// noopTracer is the default Tracer; its spans do nothing.
+type noopTracer struct{}
+
+func (noopTracer) StartSpan(string) Span { return noopSpan{} }
+
+type noopSpan struct{}
+
+func (noopSpan) End() {}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingTracer collects the name and duration of every finished span.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []recordedSpan
+}
+
+type recordedSpan struct {
+	name     string
+	duration time.Duration
+}
+
+func (tr *recordingTracer) StartSpan(name string) Span {
+	return &recordingSpan{tracer: tr, name: name, start: time.Now()}
+}
+
+type recordingSpan struct {
+	tracer *recordingTracer
+	name   string
+	start  time.Time
+}
+
+func (s *recordingSpan) End() {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.tracer.spans = append(s.tracer.spans, recordedSpan{s.name, time.Since(s.start)})
+}
+
+func TestMockServiceTracing(t *testing.T) {
+	ctx := context.Background()
+	const responseTime = 5 * time.Millisecond
+	tracer := &recordingTracer{}
+	svc := NewMockService("Traced", WithResponseTime(responseTime), WithTracer(tracer))
+
+	svc.Connect(ctx)
+	svc.PutData(ctx, "key", "value")
+	svc.GetData(ctx, "key")
+	svc.ListKeys(ctx)
+
+	want := []string{"Connect", "PutData", "GetData", "ListKeys"}
+	if len(tracer.spans) != len(want) {
+		t.Fatalf("Expected %d spans, got %d: %+v", len(want), len(tracer.spans), tracer.spans)
+	}
+	for i, name := range want {
+		span := tracer.spans[i]
+		if span.name != name {
+			t.Errorf("Span %d: expected %s, got %s", i, name, span.name)
+		}
+		if span.duration < responseTime || span.duration > time.Second {
+			t.Errorf("Span %s: implausible duration %v", span.name, span.duration)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// TrackedService records every key written through it so that Cleanup can
+// delete them again, letting tests share a service without leaking keys
+// into each other.
+type TrackedService struct {
+	next ExternalService
+
+	mu      sync.Mutex
+	written map[string]bool
+}
+
+// NewTrackedService wraps next, tracking the keys written through it.
+func NewTrackedService(next ExternalService) *TrackedService {
+	return &TrackedService{next: next, written: make(map[string]bool)}
+}
+
+// Keys returns the tracked keys in sorted order.
+func (s *TrackedService) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.written))
+	for key := range s.written {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Cleanup deletes every tracked key from the wrapped service. Keys that
+// are already gone are skipped. Keys that fail to delete stay tracked and
+// their errors are joined in the result.
+func (s *TrackedService) Cleanup(ctx context.Context) error {
+	var errs []error
+	for _, key := range s.Keys() {
+		err := s.next.DeleteData(ctx, key)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			errs = append(errs, fmt.Errorf("failed to clean up %s: %w", key, err))
+			continue
+		}
+		s.forget(key)
+	}
+	return errors.Join(errs...)
+}
+
+func (s *TrackedService) forget(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.written, key)
+}
+
+func (s *TrackedService) Connect(ctx context.Context) error {
+	return s.next.Connect(ctx)
+}
+
+func (s *TrackedService) Ping(ctx context.Context) error {
+	return s.next.Ping(ctx)
+}
+
+func (s *TrackedService) GetData(ctx context.Context, key string) (string, error) {
+	return s.next.GetData(ctx, key)
+}
+
+// PutData tracks key even if the write fails, since a write that timed out
+// may still have landed.
+func (s *TrackedService) PutData(ctx context.Context, key string, value string) error {
+	s.mu.Lock()
+	s.written[key] = true
+	s.mu.Unlock()
+	return s.next.PutData(ctx, key, value)
+}
+
+func (s *TrackedService) DeleteData(ctx context.Context, key string) error {
+	err := s.next.DeleteData(ctx, key)
+	if err == nil {
+		s.forget(key)
+	}
+	return err
+}
+
+func (s *TrackedService) ListKeys(ctx context.Context) ([]string, error) {
+	return s.next.ListKeys(ctx)
+}
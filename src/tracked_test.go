@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTrackedServiceCleanupFailure(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMockService("DB")
+	svc := NewTrackedService(backend)
+	if err := svc.PutData(ctx, "key", "value"); err != nil {
+		t.Fatal(err)
+	}
+	backend.Pause()
+
+	if err := svc.Cleanup(ctx); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Expected ErrUnavailable, got %v", err)
+	}
+	if got := svc.Keys(); len(got) != 1 {
+		t.Errorf("Expected the key to stay tracked after a failed cleanup, got %v", got)
+	}
+
+	backend.Resume()
+	if err := svc.Cleanup(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := backend.GetData(ctx, "key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the key to be deleted, got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// PutDataWithTTL stores data in the mock service like PutData, but the key
+// expires ttl after the write and then reads as not found. Writing the key
+// again with PutData clears the TTL.
+func (m *MockService) PutDataWithTTL(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return m.write(ctx, "PutDataWithTTL", key, value, func(k string) {
+		if m.expiresAt == nil {
+			m.expiresAt = make(map[string]time.Time)
+		}
+		m.expiresAt[k] = m.now().Add(ttl)
+	})
+}
+
+// expired reports whether storage key k has outlived its TTL, deleting it
+// from the store if so. Callers must hold m.mu.
+func (m *MockService) expired(k string) bool {
+	at, ok := m.expiresAt[k]
+	if !ok || m.now().Before(at) {
+		return false
+	}
+	// Ignore errors: the key reads as missing whether or not the delete
+	// reached the store.
+	_ = m.store.Delete(k)
+	m.forget(k)
+	return true
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrTxDone is returned by operations on a transaction that has already
+// been committed or rolled back. It is permanent.
+var ErrTxDone error = &classifiedError{"transaction has already been committed or rolled back", ErrPermanent}
+
+// Tx is a database transaction. Writes are buffered and only become visible
+// to other readers once Commit succeeds; reads inside the transaction see
+// its own uncommitted writes.
+type Tx interface {
+	GetData(ctx context.Context, key string) (string, error)
+	PutData(ctx context.Context, key string, value string) error
+	DeleteData(ctx context.Context, key string) error
+	Commit(ctx context.Context) error
+	Rollback() error
+}
+
+// txWrite is a buffered write; a nil value marks a delete.
+type txWrite struct {
+	value *string
+}
+
+type mockTx struct {
+	db     *DatabaseService
+	writes map[string]txWrite
+	order  []string
+	done   bool
+}
+
+// Begin starts a transaction.
+func (d *DatabaseService) Begin(ctx context.Context) (_ Tx, err error) {
+	m := d.MockService
+	defer m.observe(ctx, m.startOp("Begin", ""), &err)
+	release, err := m.begin(ctx, m.warmupLatency(m.responseTime))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if m.shouldFail() {
+		return nil, fmt.Errorf("failed to begin transaction on %s: %w", m.name, ErrTransient)
+	}
+	return &mockTx{db: d, writes: make(map[string]txWrite)}, nil
+}
+
+// GetData returns the transaction's own pending write for key if there is
+// one, and otherwise reads the committed value.
+func (tx *mockTx) GetData(ctx context.Context, key string) (string, error) {
+	if tx.done {
+		return "", ErrTxDone
+	}
+	if w, ok := tx.writes[key]; ok {
+		if w.value == nil {
+			return "", fmt.Errorf("key %s %w", key, ErrNotFound)
+		}
+		return *w.value, nil
+	}
+	return tx.db.GetData(ctx, key)
+}
+
+// PutData buffers a write until Commit. The write is checked against the
+// database's key and value limits straight away.
+func (tx *mockTx) PutData(ctx context.Context, key string, value string) error {
+	if tx.done {
+		return ErrTxDone
+	}
+	if err := tx.db.checkWrite(key, value); err != nil {
+		return err
+	}
+	tx.buffer(key, txWrite{value: &value})
+	return nil
+}
+
+// DeleteData buffers a delete until Commit.
+func (tx *mockTx) DeleteData(ctx context.Context, key string) error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.buffer(key, txWrite{})
+	return nil
+}
+
+func (tx *mockTx) buffer(key string, w txWrite) {
+	if _, ok := tx.writes[key]; !ok {
+		tx.order = append(tx.order, key)
+	}
+	tx.writes[key] = w
+}
+
+// Commit atomically applies every buffered write. If the commit fails none
+// of them are applied: a commit that would take the database past MaxKeys
+// under EvictReject is rejected with ErrCapacityFull before any write.
+func (tx *mockTx) Commit(ctx context.Context) (err error) {
+	if tx.done {
+		return ErrTxDone
+	}
+	m := tx.db.MockService
+	op := m.startOp("Commit", "")
+	defer m.observe(ctx, op, &err)
+	release, err := m.begin(ctx, m.warmupLatency(m.responseTime))
+	if err != nil {
+		return err
+	}
+	defer release()
+	if m.shouldFail() {
+		return fmt.Errorf("failed to commit transaction on %s: %w", m.name, ErrTransient)
+	}
+
+	tx.done = true
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Each key has a single buffered write, so applying the deletes first
+	// gives the same result while freeing room for the puts.
+	var deletes, puts []string
+	for _, key := range tx.order {
+		if tx.writes[key].value == nil {
+			deletes = append(deletes, key)
+		} else {
+			puts = append(puts, key)
+		}
+	}
+	if err := m.checkRoom(puts, deletes); err != nil {
+		return fmt.Errorf("failed to commit transaction on %s: %w", m.name, err)
+	}
+	op.writes = make([]WriteRecord, 0, len(tx.order))
+	for _, key := range deletes {
+		op.writes = append(op.writes, WriteRecord{Key: key})
+	}
+	for _, key := range puts {
+		op.writes = append(op.writes, WriteRecord{Key: key, Value: tx.writes[key].value})
+	}
+	for _, key := range deletes {
+		if err := m.remove(key); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	for _, key := range puts {
+		if err := m.save(key, *tx.writes[key].value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rollback discards every buffered write.
+func (tx *mockTx) Rollback() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	tx.writes = nil
+	tx.order = nil
+	return nil
+}
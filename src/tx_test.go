@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func newTestDatabase(t *testing.T) *DatabaseService {
+	t.Helper()
+	svc, err := NewServiceByType(ServiceConfig{Name: "Database", Type: TypeDatabase})
+	if err != nil {
+		t.Fatalf("NewServiceByType failed: %v", err)
+	}
+	return svc.(*DatabaseService)
+}
+
+func TestTxCommitAppliesAll(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	db.store.Set("stale", "old")
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	tx.PutData(ctx, "a", "1")
+	tx.PutData(ctx, "b", "2")
+	tx.DeleteData(ctx, "stale")
+
+	if _, err := db.GetData(ctx, "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Uncommitted write visible outside the transaction: %v", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	for k, want := range map[string]string{"a": "1", "b": "2"} {
+		if got, err := db.GetData(ctx, k); err != nil || got != want {
+			t.Errorf("Key %s: expected %q, got %q, %v", k, want, got, err)
+		}
+	}
+	if _, err := db.GetData(ctx, "stale"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected committed delete to remove key, got %v", err)
+	}
+	if err := tx.Commit(ctx); !errors.Is(err, ErrTxDone) {
+		t.Errorf("Expected ErrTxDone on second commit, got %v", err)
+	}
+}
+
+func TestTxRollbackAppliesNone(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	db.store.Set("keep", "value")
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	tx.PutData(ctx, "a", "1")
+	tx.DeleteData(ctx, "keep")
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if _, err := db.GetData(ctx, "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Rolled back write was applied: %v", err)
+	}
+	if got, err := db.GetData(ctx, "keep"); err != nil || got != "value" {
+		t.Errorf("Rolled back delete was applied: %q, %v", got, err)
+	}
+	if err := tx.PutData(ctx, "b", "2"); !errors.Is(err, ErrTxDone) {
+		t.Errorf("Expected ErrTxDone after rollback, got %v", err)
+	}
+}
+
+func TestTxReadsOwnWrites(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	db.store.Set("existing", "committed")
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	defer tx.Rollback()
+
+	tx.PutData(ctx, "new", "pending")
+	tx.DeleteData(ctx, "existing")
+
+	if got, err := tx.GetData(ctx, "new"); err != nil || got != "pending" {
+		t.Errorf("Expected own write %q, got %q, %v", "pending", got, err)
+	}
+	if _, err := tx.GetData(ctx, "existing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected own delete to hide key, got %v", err)
+	}
+	if got, err := db.GetData(ctx, "existing"); err != nil || got != "committed" {
+		t.Errorf("Expected committed value outside tx, got %q, %v", got, err)
+	}
+}
+
+func TestTxValidatesWrites(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	db.MaxValueSize = 4
+	db.MaxKeyLength = 3
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	defer tx.Rollback()
+	if err := tx.PutData(ctx, "a", "too long"); !errors.Is(err, ErrValueTooLarge) {
+		t.Errorf("Expected ErrValueTooLarge, got %v", err)
+	}
+	if err := tx.PutData(ctx, "long", "v"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey, got %v", err)
+	}
+}
+
+func TestTxFailedCommitAppliesNone(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	db.MaxKeys = 3
+	db.Eviction = EvictReject
+	for _, k := range []string{"a", "b"} {
+		if err := db.PutData(ctx, k, "old"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	tx.PutData(ctx, "a", "new")
+	tx.PutData(ctx, "c", "new")
+	tx.PutData(ctx, "d", "new")
+	if err := tx.Commit(ctx); !errors.Is(err, ErrCapacityFull) {
+		t.Fatalf("Expected ErrCapacityFull, got %v", err)
+	}
+	keys, err := db.ListKeys(ctx)
+	if err != nil || len(keys) != 2 {
+		t.Errorf("Expected the store to be unchanged, got %v, %v", keys, err)
+	}
+	if got, err := db.GetData(ctx, "a"); err != nil || got != "old" {
+		t.Errorf("Expected a to keep its old value, got %q, %v", got, err)
+	}
+
+	// Deletes in the same transaction free room for its puts.
+	tx, err = db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	tx.PutData(ctx, "c", "new")
+	tx.PutData(ctx, "d", "new")
+	tx.DeleteData(ctx, "b")
+	if err := tx.Commit(ctx); err != nil {
+		t.Errorf("Expected the commit to fit after its delete, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import "fmt"
+
+// Service types understood by NewServiceByType.
+const (
+	TypeMock     = "mock"
+	TypeStorage  = "mock-s3"
+	TypeDatabase = "mock-postgres"
+	TypeAPI      = "mock-rest"
+	TypeHTTP     = "mock-http"
+)
+
+const (
+	// databaseMaxValueSize mirrors the row size limits of typical databases.
+	databaseMaxValueSize = 1 << 20
+
+	// apiMaxValueSize mirrors the request body limits of typical APIs.
+	apiMaxValueSize = 1 << 20
+
+	// apiRateLimit is the number of requests per second the API type
+	// accepts before returning ErrRateLimited.
+	apiRateLimit = 100
+)
+
+// DatabaseService is a MockService with database-flavoured behaviour,
+// including transactions.
+type DatabaseService struct {
+	*MockService
+}
+
+// NewServiceByType returns a service whose behaviour matches cfg.Type:
+//
+//   - TypeMock (or empty): a plain MockService
+//   - TypeStorage: a MockService with no value size limit
+//   - TypeDatabase: a *DatabaseService supporting transactions, with a
+//     1 MiB value size limit
+//   - TypeAPI: a MockService with a 1 MiB value size limit and a rate limit
+//   - TypeHTTP: a TypeAPI service reached over real HTTP through an
+//     *HTTPMockService, which must be closed after use
+//
+// Unknown types return an error.
+func NewServiceByType(cfg ServiceConfig) (ExternalService, error) {
+	newMock := func(opts ...Option) *MockService {
+		opts = append([]Option{
+			WithResponseTime(cfg.ResponseTime),
+			WithFailureRate(cfg.FailureRate),
+			WithDryRun(cfg.DryRun),
+		}, opts...)
+		return NewMockService(cfg.Name, opts...)
+	}
+
+	switch cfg.Type {
+	case "", TypeMock, TypeStorage:
+		return newMock(), nil
+	case TypeDatabase:
+		svc := newMock(WithMaxValueSize(databaseMaxValueSize))
+		return &DatabaseService{MockService: svc}, nil
+	case TypeAPI:
+		return newMock(WithMaxValueSize(apiMaxValueSize), WithRateLimit(apiRateLimit)), nil
+	case TypeHTTP:
+		svc, err := NewHTTPMockService(newMock(WithMaxValueSize(apiMaxValueSize), WithRateLimit(apiRateLimit)))
+		if err != nil {
+			return nil, err
+		}
+		return svc, nil
+	default:
+		return nil, fmt.Errorf("unknown service type %q", cfg.Type)
+	}
+}
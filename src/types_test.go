@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewServiceByType(t *testing.T) {
+	ctx := context.Background()
+	largeValue := strings.Repeat("x", databaseMaxValueSize+1)
+
+	t.Run("storage accepts large values", func(t *testing.T) {
+		svc, err := NewServiceByType(ServiceConfig{Name: "Storage", Type: TypeStorage})
+		if err != nil {
+			t.Fatalf("NewServiceByType failed: %v", err)
+		}
+		if err := svc.PutData(ctx, "big", largeValue); err != nil {
+			t.Errorf("Expected storage to accept a large value, got %v", err)
+		}
+	})
+
+	t.Run("database supports database features", func(t *testing.T) {
+		svc, err := NewServiceByType(ServiceConfig{Name: "Database", Type: TypeDatabase})
+		if err != nil {
+			t.Fatalf("NewServiceByType failed: %v", err)
+		}
+		db, ok := svc.(*DatabaseService)
+		if !ok {
+			t.Fatalf("Expected *DatabaseService, got %T", svc)
+		}
+		tx, err := db.Begin(ctx)
+		if err != nil {
+			t.Fatalf("Begin failed: %v", err)
+		}
+		tx.Rollback()
+		if err := svc.PutData(ctx, "big", largeValue); !errors.Is(err, ErrValueTooLarge) {
+			t.Errorf("Expected ErrValueTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("api enforces rate limits", func(t *testing.T) {
+		svc, err := NewServiceByType(ServiceConfig{Name: "API", Type: TypeAPI})
+		if err != nil {
+			t.Fatalf("NewServiceByType failed: %v", err)
+		}
+		var limited int
+		for i := 0; i < apiRateLimit+10; i++ {
+			if err := svc.Ping(ctx); errors.Is(err, ErrRateLimited) {
+				limited++
+			} else if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		}
+		if limited != 10 {
+			t.Errorf("Expected 10 rate limited calls, got %d", limited)
+		}
+	})
+
+	t.Run("http goes over the wire", func(t *testing.T) {
+		svc, err := NewServiceByType(ServiceConfig{Name: "API", Type: TypeHTTP})
+		if err != nil {
+			t.Fatalf("NewServiceByType failed: %v", err)
+		}
+		httpSvc, ok := svc.(*HTTPMockService)
+		if !ok {
+			t.Fatalf("Expected *HTTPMockService, got %T", svc)
+		}
+		defer httpSvc.Close()
+		if err := svc.PutData(ctx, "key", largeValue); !errors.Is(err, ErrValueTooLarge) {
+			t.Errorf("Expected ErrValueTooLarge over HTTP, got %v", err)
+		}
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		if _, err := NewServiceByType(ServiceConfig{Name: "Unknown", Type: "mock-ftp"}); err == nil {
+			t.Error("Expected an error for an unknown service type")
+		}
+	})
+}
@@ -0,0 +1,52 @@
+//go:build integration
+// +build integration
+
+package tests
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestTrackKeysSubtests(t *testing.T) {
+	ctx := context.Background()
+	shared := newMemoryService()
+	put := func(t *testing.T, svc keyService, keys ...string) {
+		t.Helper()
+		for _, key := range keys {
+			if err := svc.PutData(ctx, key, "value"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	t.Run("parent", func(t *testing.T) {
+		put(t, TrackKeys(t, shared), "parent/key")
+
+		t.Run("first", func(t *testing.T) {
+			svc := TrackKeys(t, shared)
+			put(t, svc, "first/a", "first/b", "first/c")
+			if err := svc.DeleteData(ctx, "first/c"); err != nil {
+				t.Fatal(err)
+			}
+			if want := []string{"first/a", "first/b"}; !reflect.DeepEqual(svc.Keys(), want) {
+				t.Errorf("Expected tracked keys %v, got %v", want, svc.Keys())
+			}
+		})
+		t.Run("second", func(t *testing.T) {
+			put(t, TrackKeys(t, shared), "second/key")
+			if want := []string{"parent/key", "second/key"}; !reflect.DeepEqual(shared.keys(), want) {
+				t.Errorf("Expected the first subtest's keys to be gone, got %v", shared.keys())
+			}
+		})
+
+		if want := []string{"parent/key"}; !reflect.DeepEqual(shared.keys(), want) {
+			t.Errorf("Expected only the parent's keys after its subtests, got %v", shared.keys())
+		}
+	})
+
+	if keys := shared.keys(); len(keys) != 0 {
+		t.Errorf("Expected every test's keys to be cleaned up, got %v", keys)
+	}
+}
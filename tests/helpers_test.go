@@ -0,0 +1,156 @@
+//go:build integration
+// +build integration
+
+package tests
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// setFlag sets a flag for the duration of the test.
+func setFlag(t *testing.T, name, value string) {
+	t.Helper()
+	old := flag.Lookup(name).Value.String()
+	if err := flag.Set(name, value); err != nil {
+		t.Fatalf("Setting -%s=%s: %v", name, value, err)
+	}
+	t.Cleanup(func() { flag.Set(name, old) })
+}
+
+// fakeTB captures errors reported by code under test so a deliberately
+// failing MockIntegrationTest does not fail the test that runs it.
+type fakeTB struct {
+	testing.TB
+	mu     sync.Mutex
+	errors []string
+}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Failed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.errors) > 0
+}
+
+// useResultCollector swaps in a fresh suite result collector for the
+// duration of the test so its runs stay out of the real suite report.
+func useResultCollector(t *testing.T) *resultCollector {
+	t.Helper()
+	old := suiteResults
+	suiteResults = &resultCollector{}
+	t.Cleanup(func() { suiteResults = old })
+	return suiteResults
+}
+
+// keyService is the part of a key-value service that TrackKeys needs. The
+// demo's ExternalService implementations satisfy it.
+type keyService interface {
+	PutData(ctx context.Context, key string, value string) error
+	DeleteData(ctx context.Context, key string) error
+}
+
+// trackedKeys records every key written through it so that the cleanup
+// TrackKeys registers can delete them again.
+type trackedKeys struct {
+	keyService
+
+	mu      sync.Mutex
+	written map[string]bool
+}
+
+// TrackKeys wraps svc so that the keys written through it are deleted when
+// t and its subtests complete, letting tests share a service without
+// leaking keys into each other. Keys deleted through the returned service
+// are no longer tracked. Cleanup failures fail t.
+func TrackKeys(t testing.TB, svc keyService) *trackedKeys {
+	t.Helper()
+	tracked := &trackedKeys{keyService: svc, written: make(map[string]bool)}
+	t.Cleanup(func() {
+		for _, key := range tracked.Keys() {
+			if err := svc.DeleteData(context.Background(), key); err != nil {
+				t.Errorf("TrackKeys: failed to clean up %s: %v", key, err)
+			}
+		}
+	})
+	return tracked
+}
+
+// PutData tracks key even if the write fails, since a write that timed out
+// may still have landed.
+func (s *trackedKeys) PutData(ctx context.Context, key string, value string) error {
+	s.mu.Lock()
+	s.written[key] = true
+	s.mu.Unlock()
+	return s.keyService.PutData(ctx, key, value)
+}
+
+func (s *trackedKeys) DeleteData(ctx context.Context, key string) error {
+	err := s.keyService.DeleteData(ctx, key)
+	if err == nil {
+		s.mu.Lock()
+		delete(s.written, key)
+		s.mu.Unlock()
+	}
+	return err
+}
+
+// Keys returns the tracked keys in sorted order.
+func (s *trackedKeys) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.written))
+	for key := range s.written {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// memoryService is an in-memory keyService shared between tests.
+type memoryService struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func newMemoryService() *memoryService {
+	return &memoryService{data: make(map[string]string)}
+}
+
+func (s *memoryService) PutData(ctx context.Context, key string, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = value
+	return nil
+}
+
+func (s *memoryService) DeleteData(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data[key]; !ok {
+		return fmt.Errorf("key %s not found", key)
+	}
+	delete(s.data, key)
+	return nil
+}
+
+// keys returns the stored keys in sorted order.
+func (s *memoryService) keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.data))
+	for key := range s.data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
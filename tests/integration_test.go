@@ -1,3 +1,4 @@
+//go:build integration
 // +build integration
 
 package tests
@@ -5,8 +6,9 @@ package tests
 import (
 	"context"
 	"flag"
-	"math/rand"
+	"fmt"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -17,22 +19,80 @@ var (
 	runAPITests     = flag.Bool("api", false, "Run API integration tests")
 	simulateFailure = flag.Bool("fail", false, "Simulate random test failures")
 	verbose         = flag.Bool("v", false, "Verbose output")
+	opsFlag         = flag.String("ops", "", "Comma-separated operations overriding each test's defaults")
+	opCount         = flag.Int("opcount", 0, "Run N generic operations instead of each test's defaults")
+	opTimeout       = flag.Duration("optimeout", 0, "Fail any operation that runs longer than this (0 disables)")
+	iterations      = flag.Int("iterations", 1, "Run each integration test N times as separate sub-tests")
 )
 
 // MockIntegrationTest simulates an integration test with configurable behavior
 type MockIntegrationTest struct {
-	name         string
-	duration     time.Duration
-	failureRate  float32
-	operations   []string
+	name        string
+	duration    time.Duration
+	failureRate float32
+	operations  []string
+	durations   []time.Duration
+
+	// maxRetries is how many times a failed operation is retried before
+	// the test fails. It is zero unless set with WithRetries.
+	maxRetries int
+
+	// retryBackoff is the base delay before the first retry, doubling
+	// with each further attempt and jittered.
+	retryBackoff time.Duration
+
+	// failOp decides whether an attempt of op fails. When nil, failures
+	// are random and only simulated with -fail.
+	failOp func(op string, attempt int) bool
+
+	// warmup is how many unmeasured passes over the operations run before
+	// the measured one.
+	warmup int
+
+	// jitter varies each operation's simulated time uniformly by up to
+	// this much either way.
+	jitter time.Duration
+}
+
+// Option configures a MockIntegrationTest.
+type Option func(*MockIntegrationTest)
+
+// WithWarmup runs the operations n times before the measured run,
+// discarding their timings and failures, so that the measured run is not
+// skewed by cold caches and connections.
+func WithWarmup(n int) Option {
+	return func(m *MockIntegrationTest) {
+		m.warmup = n
+	}
+}
+
+// defaultRetryBackoff is the base delay before the first retry.
+const defaultRetryBackoff = 20 * time.Millisecond
+
+// WithRetries retries each failed operation up to n times before the test
+// fails. By default operations are not retried, so every simulated
+// failure fails the test.
+func WithRetries(n int) Option {
+	return func(m *MockIntegrationTest) {
+		m.maxRetries = n
+	}
+}
+
+// WithJitter varies each operation's simulated time uniformly within d of
+// its nominal time, so that latency percentiles have a spread to measure.
+func WithJitter(d time.Duration) Option {
+	return func(m *MockIntegrationTest) {
+		m.jitter = d
+	}
 }
 
 // NewMockIntegrationTest creates a new mock integration test
-func NewMockIntegrationTest(name string, duration time.Duration, failureRate float32) *MockIntegrationTest {
-	return &MockIntegrationTest{
-		name:        name,
-		duration:    duration,
-		failureRate: failureRate,
+func NewMockIntegrationTest(name string, duration time.Duration, failureRate float32, opts ...Option) *MockIntegrationTest {
+	m := &MockIntegrationTest{
+		name:         name,
+		duration:     duration,
+		failureRate:  failureRate,
+		retryBackoff: defaultRetryBackoff,
 		operations: []string{
 			"Connecting to service",
 			"Authenticating",
@@ -44,32 +104,239 @@ func NewMockIntegrationTest(name string, duration time.Duration, failureRate flo
 			"Cleaning up test data",
 		},
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
-// Run executes the mock integration test
-func (m *MockIntegrationTest) Run(t *testing.T) {
+// Run executes the mock integration test and returns its result, which is
+// also collected for the suite report.
+func (m *MockIntegrationTest) Run(t testing.TB) (result Result) {
+	result.Name = m.name
+	runStart := time.Now()
+	defer func() {
+		result.Duration = time.Since(runStart)
+		result.P50 = percentile(m.durations, 50)
+		result.P90 = percentile(m.durations, 90)
+		result.P95 = percentile(m.durations, 95)
+		result.P99 = percentile(m.durations, 99)
+		result.Ops = len(m.durations)
+		suiteResults.add(result)
+	}()
+
+	ops, err := operationOverride()
+	if err != nil {
+		t.Fatalf("Invalid operations flags: %v", err)
+	}
+	if ops != nil {
+		m.operations = ops
+	}
+
 	t.Logf("Starting %s integration test", m.name)
-	
+	m.warmUp(t)
+	m.durations = m.durations[:0]
+	defer m.logLatency(t)
+
 	for i, op := range m.operations {
 		if *verbose {
 			t.Logf("  [%d/%d] %s...", i+1, len(m.operations), op)
 		}
-		
-		// Simulate operation time
-		time.Sleep(m.duration / time.Duration(len(m.operations)))
-		
-		// Simulate random failures if enabled
-		if *simulateFailure && rand.Float32() < m.failureRate {
-			t.Errorf("  ✗ %s failed: simulated failure", op)
-			return
+
+		start := time.Now()
+		err := m.runWithTimeout(t, op)
+		m.durations = append(m.durations, time.Since(start))
+		if err != nil {
+			result.Operation = op
+			result.Failure = fmt.Sprintf("%s failed: %v", op, err)
+			t.Errorf("  ✗ %s", result.Failure)
+			return result
 		}
-		
+
 		if *verbose {
 			t.Logf("  ✓ %s completed", op)
 		}
 	}
-	
+
 	t.Logf("✅ %s integration test passed", m.name)
+	return result
+}
+
+// warmUp runs the operations m.warmup times without recording timings or
+// failing the test.
+func (m *MockIntegrationTest) warmUp(t testing.TB) {
+	for pass := 1; pass <= m.warmup; pass++ {
+		if *verbose {
+			t.Logf("  Warmup pass %d/%d", pass, m.warmup)
+		}
+		for _, op := range m.operations {
+			if err := m.runWithTimeout(t, op); err != nil && *verbose {
+				t.Logf("  (warmup) %s failed: %v", op, err)
+			}
+		}
+	}
+}
+
+// runWithTimeout runs op, failing it if it takes longer than -optimeout.
+// The operation runs in its own goroutine so that a hung operation cannot
+// block the suite.
+func (m *MockIntegrationTest) runWithTimeout(t testing.TB, op string) error {
+	ctx := context.Background()
+	if *opTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *opTimeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- m.runOperation(ctx, t, op)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %v", *opTimeout)
+	}
+}
+
+// runOperation simulates a single operation, retrying failed attempts with
+// jittered exponential backoff. It gives up early rather than sleep past
+// the test's deadline, and stops as soon as ctx is done.
+func (m *MockIntegrationTest) runOperation(ctx context.Context, t testing.TB, op string) error {
+	for attempt := 0; ; attempt++ {
+		// Simulate operation time
+		if err := sleep(ctx, m.opTime()); err != nil {
+			return err
+		}
+
+		if !m.fails(op, attempt) {
+			return nil
+		}
+		if attempt >= m.maxRetries {
+			return fmt.Errorf("simulated failure")
+		}
+
+		wait := m.backoff(attempt)
+		if dt, ok := t.(interface{ Deadline() (time.Time, bool) }); ok {
+			if deadline, ok := dt.Deadline(); ok && time.Until(deadline) < wait {
+				return fmt.Errorf("simulated failure (no time left to retry before test deadline)")
+			}
+		}
+		if *verbose {
+			t.Logf("  ↻ %s failed, retrying in %v (%d/%d)", op, wait, attempt+1, m.maxRetries)
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// sleep pauses for d, returning early with ctx's error if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// opTime returns how long one attempt of an operation takes: an even share
+// of the test's duration, varied by up to jitter either way.
+func (m *MockIntegrationTest) opTime() time.Duration {
+	d := m.duration / time.Duration(len(m.operations))
+	if m.jitter > 0 {
+		d += time.Duration(rng.Int63n(int64(2*m.jitter)+1)) - m.jitter
+	}
+	return max(d, 0)
+}
+
+// fails reports whether the given attempt of op fails.
+func (m *MockIntegrationTest) fails(op string, attempt int) bool {
+	if m.failOp != nil {
+		return m.failOp(op, attempt)
+	}
+	return *simulateFailure && rng.Float32() < m.failureRate
+}
+
+// backoff returns the jittered delay before retrying after attempt: a
+// random duration between half and all of retryBackoff*2^attempt.
+func (m *MockIntegrationTest) backoff(attempt int) time.Duration {
+	d := m.retryBackoff << attempt
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rng.Int63n(int64(d/2)+1))
+}
+
+// operationOverride returns the operations requested with -ops or -opcount,
+// or nil when neither is set and tests should use their defaults.
+func operationOverride() ([]string, error) {
+	if *opsFlag != "" && *opCount != 0 {
+		return nil, fmt.Errorf("-ops and -opcount are mutually exclusive")
+	}
+	if *opCount < 0 {
+		return nil, fmt.Errorf("-opcount must not be negative, got %d", *opCount)
+	}
+	if *opCount > 0 {
+		ops := make([]string, *opCount)
+		for i := range ops {
+			ops[i] = fmt.Sprintf("Operation %d", i+1)
+		}
+		return ops, nil
+	}
+	if strings.TrimSpace(*opsFlag) == "" {
+		return nil, nil
+	}
+	var ops []string
+	for _, op := range strings.Split(*opsFlag, ",") {
+		op = strings.TrimSpace(op)
+		if op == "" {
+			return nil, fmt.Errorf("-ops contains an empty operation: %q", *opsFlag)
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// runIterations runs fn once, or -iterations times as sub-tests named
+// "iteration N" so that each run's failure is reported on its own, and
+// logs how many of the iterations passed.
+func runIterations(t *testing.T, fn func(t *testing.T)) {
+	if *iterations <= 1 {
+		fn(t)
+		return
+	}
+	passed := 0
+	for i := 1; i <= *iterations; i++ {
+		if t.Run(fmt.Sprintf("iteration %d", i), fn) {
+			passed++
+		}
+	}
+	t.Logf("%d of %d iterations passed", passed, *iterations)
+}
+
+// Durations returns how long each operation of the last Run took, in order.
+func (m *MockIntegrationTest) Durations() []time.Duration {
+	return append([]time.Duration(nil), m.durations...)
+}
+
+// logLatency logs percentile and total latency for the last Run.
+func (m *MockIntegrationTest) logLatency(t testing.TB) {
+	if len(m.durations) == 0 {
+		return
+	}
+	var total time.Duration
+	for _, d := range m.durations {
+		total += d
+	}
+	t.Logf("  Latency: p50=%v p90=%v p95=%v p99=%v total=%v",
+		percentile(m.durations, 50), percentile(m.durations, 90), percentile(m.durations, 95),
+		percentile(m.durations, 99), total)
 }
 
 func TestStorageIntegration(t *testing.T) {
@@ -99,7 +366,7 @@ func TestStorageIntegration(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			test.fn(t, ctx)
+			runIterations(t, func(t *testing.T) { test.fn(t, ctx) })
 		})
 	}
 }
@@ -187,7 +454,7 @@ func TestDatabaseIntegration(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			test.fn(t)
+			runIterations(t, test.fn)
 		})
 	}
 }
@@ -238,17 +505,21 @@ func testDBTransaction(t *testing.T) {
 }
 
 func testDBPerformance(t *testing.T) {
-	mock := NewMockIntegrationTest("Database Performance", 1000*time.Millisecond, 0.05)
-	mock.operations = []string{
-		"Running query benchmarks",
-		"Testing connection pool",
-		"Measuring latency",
-		"Analyzing query plans",
-		"Generating performance report",
+	mock := NewMockIntegrationTest("Database Performance", 1000*time.Millisecond, 0.05,
+		WithWarmup(1), WithJitter(5*time.Millisecond))
+	mock.operations = []string{"Running query benchmarks", "Testing connection pool"}
+	for i := 0; i < latencySamples; i++ {
+		mock.operations = append(mock.operations, "Measuring query latency")
 	}
+	mock.operations = append(mock.operations, "Analyzing query plans", "Generating performance report")
+
 	mock.Run(t)
 }
 
+// latencySamples is how many times testDBPerformance measures query
+// latency.
+const latencySamples = 50
+
 func TestAPIIntegration(t *testing.T) {
 	if !*runAPITests {
 		t.Skip("API tests not enabled (use -api flag)")
@@ -276,7 +547,7 @@ func TestAPIIntegration(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			test.fn(t)
+			runIterations(t, test.fn)
 		})
 	}
 }
@@ -360,9 +631,21 @@ func TestIntegrationSuite(t *testing.T) {
 	t.Logf("  API Tests: %v", *runAPITests)
 	t.Logf("  Simulate Failures: %v", *simulateFailure)
 	t.Logf("  Verbose: %v", *verbose)
-}
 
-func init() {
-	// Random number generator is automatically seeded in Go 1.20+
-	// No need to call rand.Seed
-}
\ No newline at end of file
+	if *workers > 0 {
+		scenarios, err := LoadScenarios("testdata/suite.json")
+		if err != nil {
+			t.Fatalf("Loading suite: %v", err)
+		}
+		results, err := RunSuite(context.Background(), scenarios, *workers)
+		if err != nil {
+			t.Fatalf("RunSuite: %v", err)
+		}
+		for _, r := range results {
+			if !r.Passed() {
+				t.Errorf("  ✗ %s: %s", r.Name, r.Failure)
+			}
+		}
+		t.Logf("Ran %d suite groups on %d workers", len(results), *workers)
+	}
+}
@@ -0,0 +1,59 @@
+//go:build integration
+// +build integration
+
+package tests
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIterations(t *testing.T) {
+	results := useResultCollector(t)
+	setFlag(t, "iterations", "30")
+	setFlag(t, "fail", "true")
+	old := rng
+	t.Cleanup(func() { rng = old })
+	seedRNG(1)
+
+	runs := 0
+	runIterations(t, func(t *testing.T) {
+		runs++
+		mock := NewMockIntegrationTest("Flaky", 2*time.Millisecond, 0.3)
+		mock.operations = []string{"Connect", "Write"}
+		mock.retryBackoff = 0
+		mock.Run(&fakeTB{TB: t})
+	})
+
+	if runs != 30 {
+		t.Fatalf("Expected 30 iterations, got %d", runs)
+	}
+	all := results.all()
+	if len(all) != 30 {
+		t.Fatalf("Expected a result per iteration, got %d", len(all))
+	}
+	passed := 0
+	for _, r := range all {
+		if r.Passed() {
+			passed++
+		} else if r.Operation == "" {
+			t.Errorf("Expected a failed iteration to record its failing operation, got %+v", r)
+		}
+	}
+	if passed == 0 || passed == len(all) {
+		t.Errorf("Expected a mix of passing and failing iterations, %d of %d passed", passed, len(all))
+	}
+}
+
+func TestSingleIterationRunsInline(t *testing.T) {
+	calls := 0
+	runIterations(t, func(sub *testing.T) {
+		calls++
+		if sub != t {
+			t.Error("Expected a single iteration to run without a sub-test")
+		}
+	})
+	if calls != 1 {
+		t.Errorf("Expected one call, got %d", calls)
+	}
+}
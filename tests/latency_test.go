@@ -0,0 +1,112 @@
+//go:build integration
+// +build integration
+
+package tests
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"testing"
+	"time"
+)
+
+// percentile returns the p-th percentile (0-100) of durations using the
+// nearest-rank method.
+func percentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func TestPercentile(t *testing.T) {
+	durations := make([]time.Duration, 100)
+	for i := range durations {
+		durations[len(durations)-1-i] = time.Duration(i+1) * time.Millisecond
+	}
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0, 1 * time.Millisecond},
+		{50, 50 * time.Millisecond},
+		{95, 95 * time.Millisecond},
+		{99, 99 * time.Millisecond},
+		{100, 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := percentile(durations, tt.p); got != tt.want {
+			t.Errorf("percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestMockIntegrationTestLatency(t *testing.T) {
+	useResultCollector(t)
+	mock := NewMockIntegrationTest("Latency", 40*time.Millisecond, 0)
+	mock.operations = []string{"one", "two", "three", "four"}
+	perOp := 10 * time.Millisecond
+
+	mock.Run(t)
+
+	durations := mock.Durations()
+	if len(durations) != len(mock.operations) {
+		t.Fatalf("Expected %d durations, got %d", len(mock.operations), len(durations))
+	}
+	for _, p := range []float64{50, 95, 99} {
+		got := percentile(durations, p)
+		if got < perOp || got > 3*perOp {
+			t.Errorf("p%v = %v, expected close to %v", p, got, perOp)
+		}
+	}
+}
+
+func TestMockIntegrationTestJitteredPercentiles(t *testing.T) {
+	useResultCollector(t)
+	const (
+		perOp  = 10 * time.Millisecond
+		jitter = 4 * time.Millisecond
+		ops    = 30
+	)
+	mock := NewMockIntegrationTest("Jittered", ops*perOp, 0, WithJitter(jitter))
+	mock.operations = make([]string, ops)
+	for i := range mock.operations {
+		mock.operations[i] = fmt.Sprintf("sample %d", i+1)
+	}
+
+	result := mock.Run(t)
+
+	if !(result.P50 <= result.P90 && result.P90 <= result.P95 && result.P95 <= result.P99) {
+		t.Errorf("Expected ordered percentiles, got p50=%v p90=%v p95=%v p99=%v", result.P50, result.P90, result.P95, result.P99)
+	}
+	// Sleeps never end early but may overrun under load.
+	lo, hi := perOp-jitter, perOp+jitter+10*time.Millisecond
+	for name, got := range map[string]time.Duration{"p50": result.P50, "p90": result.P90, "p95": result.P95, "p99": result.P99} {
+		if got < lo || got > hi {
+			t.Errorf("%s = %v, expected within [%v, %v]", name, got, lo, hi)
+		}
+	}
+}
+
+func TestOpTimeJitter(t *testing.T) {
+	mock := NewMockIntegrationTest("Jitter", 100*time.Millisecond, 0, WithJitter(5*time.Millisecond))
+	mock.operations = []string{"only"}
+	seen := map[bool]bool{}
+	for i := 0; i < 200; i++ {
+		d := mock.opTime()
+		if d < 95*time.Millisecond || d > 105*time.Millisecond {
+			t.Fatalf("opTime() = %v, expected within 5ms of 100ms", d)
+		}
+		seen[d < 100*time.Millisecond] = true
+	}
+	if len(seen) != 2 {
+		t.Error("Expected jitter to vary the operation time both ways")
+	}
+}
@@ -0,0 +1,132 @@
+//go:build integration
+// +build integration
+
+package tests
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	seed      = flag.Int64("seed", 0, "Seed for failure simulation (0 picks a random seed)")
+	junitPath = flag.String("junit", "", "Write a JUnit XML report of the integration tests to this path")
+	csvPath   = flag.String("csv", "", "Write a CSV report of the integration tests to this path")
+)
+
+// rng drives failure simulation. TestMain seeds it from -seed, or from a
+// random seed it prints, so a run can be reproduced exactly. It is safe
+// for concurrent use, as RunSuite runs several tests at once.
+var rng = rand.New(&lockedSource{src: rand.NewSource(time.Now().UnixNano())})
+
+// seedRNG makes failure simulation deterministic for the given seed.
+func seedRNG(seed int64) {
+	rng = rand.New(&lockedSource{src: rand.NewSource(seed)})
+}
+
+// lockedSource is a rand.Source safe for concurrent use.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	seedRNG(*seed)
+	if _, err := operationOverride(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid flags: %v\n", err)
+		os.Exit(2)
+	}
+	if *iterations < 1 {
+		fmt.Fprintf(os.Stderr, "Invalid flags: -iterations must be at least 1, got %d\n", *iterations)
+		os.Exit(2)
+	}
+
+	fmt.Println("Integration test configuration:")
+	fmt.Printf("  Storage Tests: %v\n", *runStorageTests)
+	fmt.Printf("  Database Tests: %v\n", *runDBTests)
+	fmt.Printf("  API Tests: %v\n", *runAPITests)
+	fmt.Printf("  Simulate Failures: %v\n", *simulateFailure)
+	fmt.Printf("  Seed: %d (rerun with -seed=%d to reproduce)\n", *seed, *seed)
+
+	code := m.Run()
+
+	if results := suiteResults.all(); len(results) > 0 {
+		fmt.Println()
+		WriteSummary(os.Stdout, results)
+	}
+
+	if *junitPath != "" {
+		if err := writeJUnitFile(*junitPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write JUnit report: %v\n", err)
+			if code == 0 {
+				code = 1
+			}
+		}
+	}
+	if *csvPath != "" {
+		if err := writeCSVFile(*csvPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write CSV report: %v\n", err)
+			if code == 0 {
+				code = 1
+			}
+		}
+	}
+
+	os.Exit(code)
+}
+
+func TestSeedReproducesFailures(t *testing.T) {
+	useResultCollector(t)
+	setFlag(t, "fail", "true")
+	old := rng
+	t.Cleanup(func() { rng = old })
+
+	// simulate runs a flaky mock under seed and reports where it failed
+	// and how many operations it got through.
+	simulate := func(seed int64) (string, int) {
+		seedRNG(seed)
+		mock := NewMockIntegrationTest("Seeded", 20*time.Millisecond, 0.6)
+		mock.operations = make([]string, 20)
+		for i := range mock.operations {
+			mock.operations[i] = fmt.Sprintf("op %d", i+1)
+		}
+		mock.retryBackoff = 0
+		result := mock.Run(&fakeTB{TB: t})
+		return result.Failure, len(mock.Durations())
+	}
+
+	failure, ran := simulate(42)
+	if failure == "" {
+		t.Fatal("Expected seed 42 to simulate a failure")
+	}
+	for i := 0; i < 3; i++ {
+		if f, n := simulate(42); f != failure || n != ran {
+			t.Errorf("Expected seed 42 to fail after %d operations with %q, got %d and %q", ran, failure, n, f)
+		}
+	}
+	if f, n := simulate(7); f == failure && n == ran {
+		t.Errorf("Expected seed 7 to simulate different failures than seed 42, both gave %q", f)
+	}
+}
@@ -0,0 +1,70 @@
+//go:build integration
+// +build integration
+
+package tests
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOperationsFlag(t *testing.T) {
+	useResultCollector(t)
+	setFlag(t, "ops", "Upload, Verify ,Delete")
+
+	mock := NewMockIntegrationTest("Ops Override", 3*time.Millisecond, 0)
+	mock.Run(t)
+
+	want := []string{"Upload", "Verify", "Delete"}
+	if len(mock.operations) != len(want) {
+		t.Fatalf("Expected operations %v, got %v", want, mock.operations)
+	}
+	for i := range want {
+		if mock.operations[i] != want[i] {
+			t.Errorf("Operation %d: expected %q, got %q", i, want[i], mock.operations[i])
+		}
+	}
+	if got := len(mock.Durations()); got != len(want) {
+		t.Errorf("Expected %d operations to run, got %d", len(want), got)
+	}
+}
+
+func TestOpCountFlag(t *testing.T) {
+	useResultCollector(t)
+	setFlag(t, "opcount", "5")
+
+	mock := NewMockIntegrationTest("Op Count", 5*time.Millisecond, 0)
+	mock.Run(t)
+
+	if got := len(mock.Durations()); got != 5 {
+		t.Errorf("Expected 5 operations to run, got %d", got)
+	}
+}
+
+func TestOperationOverrideValidation(t *testing.T) {
+	t.Run("empty uses defaults", func(t *testing.T) {
+		ops, err := operationOverride()
+		if err != nil || ops != nil {
+			t.Errorf("Expected no override, got %v, %v", ops, err)
+		}
+	})
+
+	invalid := []struct {
+		name  string
+		flags map[string]string
+	}{
+		{"empty entry", map[string]string{"ops": "a,,b"}},
+		{"negative count", map[string]string{"opcount": "-1"}},
+		{"both set", map[string]string{"ops": "a", "opcount": "2"}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.flags {
+				setFlag(t, name, value)
+			}
+			if _, err := operationOverride(); err == nil {
+				t.Error("Expected a validation error")
+			}
+		})
+	}
+}
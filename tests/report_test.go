@@ -0,0 +1,367 @@
+//go:build integration
+// +build integration
+
+package tests
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"text/tabwriter"
+	"time"
+)
+
+// Result is the outcome of a single MockIntegrationTest run.
+type Result struct {
+	Name     string
+	Duration time.Duration
+	// Operation is the operation that failed and Failure describes why;
+	// both are empty when the run passed.
+	Operation string
+	Failure   string
+	// P50, P90, P95 and P99 are latency percentiles over the run's
+	// operations.
+	P50, P90, P95, P99 time.Duration
+	// Ops is how many operations ran, including the one that failed.
+	Ops int
+}
+
+// Passed reports whether the run succeeded.
+func (r Result) Passed() bool {
+	return r.Failure == ""
+}
+
+// resultCollector accumulates results across the whole test binary.
+type resultCollector struct {
+	mu      sync.Mutex
+	results []Result
+}
+
+func (c *resultCollector) add(r Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results = append(c.results, r)
+}
+
+func (c *resultCollector) all() []Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Result(nil), c.results...)
+}
+
+// suiteResults collects the result of every MockIntegrationTest run.
+var suiteResults = &resultCollector{}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnitReport writes results to w as a single JUnit XML testsuite.
+func WriteJUnitReport(w io.Writer, suite string, results []Result) error {
+	ts := junitTestSuite{Name: suite, Tests: len(results)}
+	var total time.Duration
+	for _, r := range results {
+		tc := junitTestCase{
+			Name:      r.Name,
+			ClassName: suite,
+			Time:      junitSeconds(r.Duration),
+		}
+		if !r.Passed() {
+			ts.Failures++
+			tc.Failure = &junitFailure{Message: r.Failure, Text: r.Failure}
+		}
+		total += r.Duration
+		ts.TestCases = append(ts.TestCases, tc)
+	}
+	ts.Time = junitSeconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(ts); err != nil {
+		return fmt.Errorf("encoding JUnit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteCSVReport writes results to w as CSV with a header row and the
+// columns test, operation, status, duration_ms and error.
+func WriteCSVReport(w io.Writer, results []Result) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"test", "operation", "status", "duration_ms", "error"}); err != nil {
+		return err
+	}
+	for _, r := range results {
+		status := "passed"
+		if !r.Passed() {
+			status = "failed"
+		}
+		ms := strconv.FormatFloat(float64(r.Duration)/float64(time.Millisecond), 'f', 3, 64)
+		if err := cw.Write([]string{r.Name, r.Operation, status, ms, r.Failure}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("writing CSV report: %w", err)
+	}
+	return nil
+}
+
+// Summary aggregates the outcomes of a suite's runs and operations.
+type Summary struct {
+	Runs, Passed, Failed int
+	OpsPassed, OpsFailed int
+	// Scenarios breaks the counts down by test name.
+	Scenarios map[string]*Summary
+}
+
+// Summarize aggregates results overall and per test name.
+func Summarize(results []Result) Summary {
+	s := Summary{Scenarios: make(map[string]*Summary)}
+	for _, r := range results {
+		sc := s.Scenarios[r.Name]
+		if sc == nil {
+			sc = &Summary{}
+			s.Scenarios[r.Name] = sc
+		}
+		for _, agg := range []*Summary{&s, sc} {
+			agg.Runs++
+			if r.Passed() {
+				agg.Passed++
+				agg.OpsPassed += r.Ops
+			} else {
+				agg.Failed++
+				agg.OpsPassed += max(r.Ops-1, 0)
+				agg.OpsFailed++
+			}
+		}
+	}
+	return s
+}
+
+// WriteSummary writes a suite-wide pass/fail count for results to w, a
+// table of runs and operations per test, and one line per failed run.
+func WriteSummary(w io.Writer, results []Result) {
+	s := Summarize(results)
+	fmt.Fprintf(w, "Integration test summary: %d run, %d passed, %d failed (operations: %d passed, %d failed)\n",
+		s.Runs, s.Passed, s.Failed, s.OpsPassed, s.OpsFailed)
+
+	names := make([]string, 0, len(s.Scenarios))
+	for name := range s.Scenarios {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  TEST\tRUNS\tPASSED\tFAILED\tOPS PASSED\tOPS FAILED")
+	for _, name := range names {
+		sc := s.Scenarios[name]
+		fmt.Fprintf(tw, "  %s\t%d\t%d\t%d\t%d\t%d\n", name, sc.Runs, sc.Passed, sc.Failed, sc.OpsPassed, sc.OpsFailed)
+	}
+	tw.Flush()
+
+	for _, r := range results {
+		if !r.Passed() {
+			fmt.Fprintf(w, "  ✗ %s: %s\n", r.Name, r.Failure)
+		}
+	}
+}
+
+// writeJUnitFile writes the collected suite results to path.
+func writeJUnitFile(path string) error {
+	return writeReportFile(path, func(w io.Writer) error {
+		return WriteJUnitReport(w, "integration", suiteResults.all())
+	})
+}
+
+// writeCSVFile writes the collected suite results to path.
+func writeCSVFile(path string) error {
+	return writeReportFile(path, func(w io.Writer) error {
+		return WriteCSVReport(w, suiteResults.all())
+	})
+}
+
+// writeReportFile creates path, along with any missing parent directories,
+// and fills it using write.
+func writeReportFile(path string, write func(io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func TestJUnitReport(t *testing.T) {
+	collector := useResultCollector(t)
+	setFlag(t, "fail", "true")
+
+	passing := NewMockIntegrationTest("Passing", 2*time.Millisecond, 0)
+	passing.operations = []string{"Connect", "Read"}
+	passing.Run(t)
+
+	tb := &fakeTB{TB: t}
+	failing := NewMockIntegrationTest("Failing", 2*time.Millisecond, 1.0)
+	failing.operations = []string{"Connect", "Write"}
+	failing.Run(tb)
+	if !tb.Failed() {
+		t.Fatal("Expected the failing mock test to report an error")
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJUnitReport(&buf, "integration", collector.all()); err != nil {
+		t.Fatalf("WriteJUnitReport failed: %v", err)
+	}
+
+	var suite junitTestSuite
+	if err := xml.Unmarshal(buf.Bytes(), &suite); err != nil {
+		t.Fatalf("Report is not valid XML: %v\n%s", err, buf.String())
+	}
+	if suite.Tests != 2 || suite.Failures != 1 || len(suite.TestCases) != 2 {
+		t.Fatalf("Expected 2 tests with 1 failure, got tests=%d failures=%d cases=%d",
+			suite.Tests, suite.Failures, len(suite.TestCases))
+	}
+	if suite.TestCases[0].Name != "Passing" || suite.TestCases[0].Failure != nil {
+		t.Errorf("Unexpected first test case: %+v", suite.TestCases[0])
+	}
+	failure := suite.TestCases[1].Failure
+	if suite.TestCases[1].Name != "Failing" || failure == nil {
+		t.Fatalf("Expected second test case to fail: %+v", suite.TestCases[1])
+	}
+	if !strings.Contains(failure.Message, "Connect failed") {
+		t.Errorf("Unexpected failure message: %q", failure.Message)
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	results := []Result{
+		{Name: "Storage", Ops: 4},
+		{Name: "Database", Ops: 2, Operation: "Write", Failure: "Write failed: simulated failure"},
+		{Name: "API", Ops: 3},
+	}
+
+	var buf bytes.Buffer
+	WriteSummary(&buf, results)
+
+	want := "Integration test summary: 3 run, 2 passed, 1 failed (operations: 8 passed, 1 failed)\n" +
+		"  TEST      RUNS  PASSED  FAILED  OPS PASSED  OPS FAILED\n" +
+		"  API       1     1       0       3           0\n" +
+		"  Database  1     0       1       1           1\n" +
+		"  Storage   1     1       0       4           0\n" +
+		"  ✗ Database: Write failed: simulated failure\n"
+	if buf.String() != want {
+		t.Errorf("Expected summary:\n%s\ngot:\n%s", want, buf.String())
+	}
+}
+
+func TestSummarizeScenarios(t *testing.T) {
+	results := useResultCollector(t)
+
+	// Every attempt of "Charge" fails, so Payments always fails on its
+	// second operation; Catalog never fails.
+	failCharge := func(m *MockIntegrationTest) {
+		m.retryBackoff = 0
+		m.failOp = func(op string, attempt int) bool { return op == "Charge" }
+	}
+	scenarios := []Scenario{
+		{Name: "Catalog", Duration: 2 * time.Millisecond, Operations: []string{"List", "Get", "Search"}},
+		{Name: "Payments", Duration: 2 * time.Millisecond, Operations: []string{"Authorize", "Charge", "Refund"}},
+	}
+	for i := 0; i < 2; i++ {
+		for _, sc := range scenarios {
+			mock := NewMockIntegrationTest(sc.Name, sc.Duration, 0, failCharge)
+			mock.operations = sc.Operations
+			mock.Run(&fakeTB{TB: t})
+		}
+	}
+
+	s := Summarize(results.all())
+	if s.Runs != 4 || s.Passed != 2 || s.Failed != 2 {
+		t.Errorf("Expected 4 runs with 2 passed and 2 failed, got %+v", s)
+	}
+	if s.OpsPassed != 8 || s.OpsFailed != 2 {
+		t.Errorf("Expected 8 passed and 2 failed operations, got %d and %d", s.OpsPassed, s.OpsFailed)
+	}
+	catalog, payments := s.Scenarios["Catalog"], s.Scenarios["Payments"]
+	if catalog == nil || payments == nil {
+		t.Fatalf("Expected a breakdown per scenario, got %v", s.Scenarios)
+	}
+	if want := (Summary{Runs: 2, Passed: 2, OpsPassed: 6}); !reflect.DeepEqual(*catalog, want) {
+		t.Errorf("Unexpected Catalog summary %+v", *catalog)
+	}
+	if want := (Summary{Runs: 2, Failed: 2, OpsPassed: 2, OpsFailed: 2}); !reflect.DeepEqual(*payments, want) {
+		t.Errorf("Unexpected Payments summary %+v", *payments)
+	}
+}
+
+func TestCSVReport(t *testing.T) {
+	results := []Result{
+		{Name: "Storage", Duration: 1500 * time.Microsecond},
+		{Name: "Database", Duration: 20 * time.Millisecond, Operation: "Write", Failure: `Write failed: "disk, full"`},
+		{Name: "API, v2", Duration: 0, Operation: "Auth", Failure: "Auth failed:\nbad token"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSVReport(&buf, results); err != nil {
+		t.Fatalf("WriteCSVReport failed: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Report is not valid CSV: %v\n%s", err, buf.String())
+	}
+	if len(rows) != len(results)+1 {
+		t.Fatalf("Expected %d rows including the header, got %d", len(results)+1, len(rows))
+	}
+	want := [][]string{
+		{"test", "operation", "status", "duration_ms", "error"},
+		{"Storage", "", "passed", "1.500", ""},
+		{"Database", "Write", "failed", "20.000", `Write failed: "disk, full"`},
+		{"API, v2", "Auth", "failed", "0.000", "Auth failed:\nbad token"},
+	}
+	for i := range want {
+		if strings.Join(rows[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("Row %d: expected %q, got %q", i, want[i], rows[i])
+		}
+	}
+}
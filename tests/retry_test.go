@@ -0,0 +1,116 @@
+//go:build integration
+// +build integration
+
+package tests
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMockIntegrationTestRetries(t *testing.T) {
+	useResultCollector(t)
+
+	t.Run("fails once then succeeds", func(t *testing.T) {
+		attempts := map[string]int{}
+		mock := NewMockIntegrationTest("Flaky", 3*time.Millisecond, 0, WithRetries(2))
+		mock.operations = []string{"Connect", "Write", "Read"}
+		mock.retryBackoff = time.Millisecond
+		mock.failOp = func(op string, attempt int) bool {
+			attempts[op]++
+			return op == "Write" && attempt == 0
+		}
+
+		result := mock.Run(t)
+		if !result.Passed() {
+			t.Errorf("Expected the test to pass after a retry, got %q", result.Failure)
+		}
+		if attempts["Write"] != 2 {
+			t.Errorf("Expected Write to be attempted twice, got %d", attempts["Write"])
+		}
+		if attempts["Connect"] != 1 || attempts["Read"] != 1 {
+			t.Errorf("Expected other operations to run once, got %v", attempts)
+		}
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		attempts := 0
+		mock := NewMockIntegrationTest("Broken", time.Millisecond, 0, WithRetries(2))
+		mock.operations = []string{"Write"}
+		mock.retryBackoff = time.Millisecond
+		mock.failOp = func(string, int) bool {
+			attempts++
+			return true
+		}
+
+		tb := &fakeTB{TB: t}
+		if result := mock.Run(tb); result.Passed() {
+			t.Error("Expected the test to fail")
+		}
+		if attempts != mock.maxRetries+1 {
+			t.Errorf("Expected %d attempts, got %d", mock.maxRetries+1, attempts)
+		}
+	})
+
+	t.Run("no retries by default", func(t *testing.T) {
+		attempts := 0
+		mock := NewMockIntegrationTest("Broken", time.Millisecond, 0)
+		mock.operations = []string{"Write"}
+		mock.failOp = func(string, int) bool {
+			attempts++
+			return true
+		}
+
+		tb := &fakeTB{TB: t}
+		if result := mock.Run(tb); result.Passed() {
+			t.Error("Expected the test to fail")
+		}
+		if attempts != 1 {
+			t.Errorf("Expected a single attempt, got %d", attempts)
+		}
+	})
+}
+
+func TestBackoffJitter(t *testing.T) {
+	mock := NewMockIntegrationTest("Backoff", 0, 0)
+	mock.retryBackoff = 10 * time.Millisecond
+	for attempt := 0; attempt < 3; attempt++ {
+		full := mock.retryBackoff << attempt
+		for i := 0; i < 20; i++ {
+			if d := mock.backoff(attempt); d < full/2 || d > full {
+				t.Fatalf("backoff(%d) = %v, expected within [%v, %v]", attempt, d, full/2, full)
+			}
+		}
+	}
+}
+
+func TestMockIntegrationTestWarmup(t *testing.T) {
+	useResultCollector(t)
+
+	attempts, writes := 0, 0
+	mock := NewMockIntegrationTest("Warmup", 3*time.Millisecond, 0, WithWarmup(2), WithRetries(2))
+	mock.operations = []string{"Connect", "Write", "Read"}
+	mock.retryBackoff = time.Millisecond
+	mock.failOp = func(op string, attempt int) bool {
+		attempts++
+		if op != "Write" {
+			return false
+		}
+		// Every attempt of the first warmup Write fails; that must not
+		// fail the test.
+		writes++
+		return writes <= mock.maxRetries+1
+	}
+
+	result := mock.Run(t)
+	if !result.Passed() {
+		t.Errorf("Expected the test to pass, got %q", result.Failure)
+	}
+	// Two warmup passes and the measured run, plus the first Write's retries.
+	if want := 3*len(mock.operations) + mock.maxRetries; attempts != want {
+		t.Errorf("Expected %d attempts including warmup, got %d", want, attempts)
+	}
+	if got := len(mock.Durations()); got != len(mock.operations) {
+		t.Errorf("Expected only the measured run's %d durations, got %d", len(mock.operations), got)
+	}
+}
@@ -0,0 +1,166 @@
+//go:build integration
+// +build integration
+
+package tests
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+var scenariosPath = flag.String("scenarios", "", "Run the scenarios defined in this JSON file")
+
+// Scenario describes a MockIntegrationTest loaded from a scenario file.
+type Scenario struct {
+	Name        string
+	Duration    time.Duration
+	FailureRate float32
+	Operations  []string
+}
+
+// scenarioFile is the JSON form of a Scenario, with the duration written
+// as a Go duration string such as "500ms".
+type scenarioFile struct {
+	Name        string   `json:"name"`
+	Duration    string   `json:"duration"`
+	FailureRate float32  `json:"failureRate"`
+	Operations  []string `json:"operations"`
+}
+
+// LoadScenarios reads a JSON array of scenarios from path.
+func LoadScenarios(path string) ([]Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw []scenarioFile
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	scenarios := make([]Scenario, 0, len(raw))
+	for i, r := range raw {
+		if r.Name == "" {
+			return nil, fmt.Errorf("scenario %d: missing name", i+1)
+		}
+		if len(r.Operations) == 0 {
+			return nil, fmt.Errorf("scenario %q: no operations", r.Name)
+		}
+		if r.FailureRate < 0 || r.FailureRate > 1 {
+			return nil, fmt.Errorf("scenario %q: failure rate %v outside [0, 1]", r.Name, r.FailureRate)
+		}
+		var d time.Duration
+		if r.Duration != "" {
+			if d, err = time.ParseDuration(r.Duration); err != nil {
+				return nil, fmt.Errorf("scenario %q: %w", r.Name, err)
+			}
+		}
+		scenarios = append(scenarios, Scenario{
+			Name:        r.Name,
+			Duration:    d,
+			FailureRate: r.FailureRate,
+			Operations:  r.Operations,
+		})
+	}
+	return scenarios, nil
+}
+
+// ScenarioRunner runs each scenario as a sub-test.
+type ScenarioRunner struct {
+	Scenarios []Scenario
+
+	// Options are applied to every scenario's MockIntegrationTest.
+	Options []Option
+}
+
+// Run executes every scenario as a sub-test of t, named after the scenario.
+func (r *ScenarioRunner) Run(t *testing.T) {
+	for _, sc := range r.Scenarios {
+		sc := sc
+		t.Run(sc.Name, func(t *testing.T) {
+			runIterations(t, func(t *testing.T) {
+				mock := NewMockIntegrationTest(sc.Name, sc.Duration, sc.FailureRate, r.Options...)
+				mock.operations = append([]string(nil), sc.Operations...)
+				mock.Run(t)
+			})
+		})
+	}
+}
+
+func TestScenarios(t *testing.T) {
+	if *scenariosPath == "" {
+		t.Skip("Scenario tests not enabled (use -scenarios flag)")
+	}
+	scenarios, err := LoadScenarios(*scenariosPath)
+	if err != nil {
+		t.Fatalf("Loading scenarios: %v", err)
+	}
+	runner := &ScenarioRunner{Scenarios: scenarios}
+	runner.Run(t)
+}
+
+func TestScenarioRunner(t *testing.T) {
+	results := useResultCollector(t)
+
+	scenarios, err := LoadScenarios("testdata/scenarios.json")
+	if err != nil {
+		t.Fatalf("LoadScenarios: %v", err)
+	}
+
+	ran := map[string][]string{}
+	record := func(m *MockIntegrationTest) {
+		name := m.name
+		m.failOp = func(op string, attempt int) bool {
+			ran[name] = append(ran[name], op)
+			return false
+		}
+	}
+	runner := &ScenarioRunner{Scenarios: scenarios, Options: []Option{record}}
+	runner.Run(t)
+
+	want := map[string][]string{
+		"Cache Warm":  {"Connecting to cache", "Priming keys", "Verifying hit rate"},
+		"Queue Drain": {"Connecting to queue", "Draining messages"},
+	}
+	for name, ops := range want {
+		if fmt.Sprint(ran[name]) != fmt.Sprint(ops) {
+			t.Errorf("Scenario %q: expected operations %v, got %v", name, ops, ran[name])
+		}
+	}
+	if got := len(results.all()); got != len(want) {
+		t.Errorf("Expected %d scenario results, got %d", len(want), got)
+	}
+}
+
+func TestLoadScenariosInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"malformed", `[{`},
+		{"missing name", `[{"operations": ["a"]}]`},
+		{"no operations", `[{"name": "x"}]`},
+		{"bad duration", `[{"name": "x", "duration": "soon", "operations": ["a"]}]`},
+		{"bad failure rate", `[{"name": "x", "failureRate": 2, "operations": ["a"]}]`},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			path := t.TempDir() + "/scenarios.json"
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadScenarios(path); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+
+	if _, err := LoadScenarios("testdata/missing.json"); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
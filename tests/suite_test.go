@@ -0,0 +1,206 @@
+//go:build integration
+// +build integration
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+var workers = flag.Int("workers", 0, "Run the groups in testdata/suite.json concurrently on N workers (0 disables)")
+
+// RunSuite runs scenarios concurrently on at most parallelism workers and
+// returns their results in scenario order. Once ctx is done no further
+// scenarios are started, and RunSuite returns the results of those that
+// ran along with ctx's error. Each scenario's log output is discarded;
+// its failure, if any, is reported in its Result.
+func RunSuite(ctx context.Context, scenarios []Scenario, parallelism int, opts ...Option) ([]Result, error) {
+	if parallelism < 1 {
+		return nil, fmt.Errorf("parallelism must be at least 1, got %d", parallelism)
+	}
+	if _, err := operationOverride(); err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, len(scenarios))
+	ran := make([]bool, len(scenarios))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(parallelism, len(scenarios)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = runSuiteScenario(scenarios[i], opts)
+				ran[i] = true
+			}
+		}()
+	}
+
+feed:
+	for i := range scenarios {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	var out []Result
+	for i, r := range results {
+		if ran[i] {
+			out = append(out, r)
+		}
+	}
+	return out, ctx.Err()
+}
+
+// runSuiteScenario runs sc in its own goroutine, as the testing package
+// runs a test, so that a Fatalf ends only this scenario.
+func runSuiteScenario(sc Scenario, opts []Option) Result {
+	mock := NewMockIntegrationTest(sc.Name, sc.Duration, sc.FailureRate, opts...)
+	mock.operations = append([]string(nil), sc.Operations...)
+
+	tb := &suiteTB{}
+	result := Result{Name: sc.Name}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		result = mock.Run(tb)
+	}()
+	<-done
+	if result.Failure == "" && tb.failure() != "" {
+		result.Failure = tb.failure()
+	}
+	return result
+}
+
+// suiteTB is the testing.TB a scenario runs against under RunSuite. It
+// discards log output and keeps the first error reported.
+type suiteTB struct {
+	testing.TB
+	mu  sync.Mutex
+	err string
+}
+
+func (s *suiteTB) Helper() {}
+
+func (s *suiteTB) Logf(format string, args ...any) {}
+
+func (s *suiteTB) Errorf(format string, args ...any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == "" {
+		s.err = fmt.Sprintf(format, args...)
+	}
+}
+
+func (s *suiteTB) Fatalf(format string, args ...any) {
+	s.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+func (s *suiteTB) Failed() bool {
+	return s.failure() != ""
+}
+
+func (s *suiteTB) failure() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func TestRunSuite(t *testing.T) {
+	useResultCollector(t)
+
+	var scenarios []Scenario
+	for i := 1; i <= 7; i++ {
+		scenarios = append(scenarios, Scenario{
+			Name:       fmt.Sprintf("Group %d", i),
+			Duration:   40 * time.Millisecond,
+			Operations: []string{"start", "finish"},
+		})
+	}
+
+	const parallelism = 3
+	var mu sync.Mutex
+	runs := map[string]int{}
+	active, maxActive := 0, 0
+	track := func(m *MockIntegrationTest) {
+		name := m.name
+		m.failOp = func(op string, attempt int) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			switch op {
+			case "start":
+				runs[name]++
+				active++
+				maxActive = max(maxActive, active)
+			case "finish":
+				active--
+			}
+			return false
+		}
+	}
+
+	results, err := RunSuite(context.Background(), scenarios, parallelism, track)
+	if err != nil {
+		t.Fatalf("RunSuite failed: %v", err)
+	}
+	if len(results) != len(scenarios) {
+		t.Fatalf("Expected %d results, got %d", len(scenarios), len(results))
+	}
+	for i, r := range results {
+		if r.Name != scenarios[i].Name || !r.Passed() {
+			t.Errorf("Result %d: expected %s to pass, got %+v", i, scenarios[i].Name, r)
+		}
+		if runs[r.Name] != 1 {
+			t.Errorf("Expected %s to run exactly once, ran %d times", r.Name, runs[r.Name])
+		}
+	}
+	if maxActive > parallelism || maxActive < 2 {
+		t.Errorf("Expected between 2 and %d scenarios running at once, got %d", parallelism, maxActive)
+	}
+}
+
+func TestRunSuiteFailures(t *testing.T) {
+	useResultCollector(t)
+	scenarios := []Scenario{
+		{Name: "Healthy", Operations: []string{"ok"}},
+		{Name: "Broken", Operations: []string{"ok", "broken"}},
+	}
+	fail := func(m *MockIntegrationTest) {
+		m.failOp = func(op string, attempt int) bool { return op == "broken" }
+		m.retryBackoff = 0
+	}
+
+	results, err := RunSuite(context.Background(), scenarios, 2, fail)
+	if err != nil {
+		t.Fatalf("RunSuite failed: %v", err)
+	}
+	if !results[0].Passed() || results[1].Passed() || results[1].Operation != "broken" {
+		t.Errorf("Expected only Broken to fail at its broken operation, got %+v", results)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err = RunSuite(ctx, scenarios, 2)
+	if !errors.Is(err, context.Canceled) || len(results) != 0 {
+		t.Errorf("Expected a canceled suite to run nothing, got %v, %v", results, err)
+	}
+	if _, err := RunSuite(context.Background(), scenarios, 0); err == nil {
+		t.Error("Expected an error for zero parallelism")
+	}
+}
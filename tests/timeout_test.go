@@ -0,0 +1,50 @@
+//go:build integration
+// +build integration
+
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOperationTimeout(t *testing.T) {
+	useResultCollector(t)
+	setFlag(t, "optimeout", "20ms")
+
+	mock := NewMockIntegrationTest("Hanging", 2*time.Second, 0)
+	mock.operations = []string{"Hang", "Never reached"}
+
+	tb := &fakeTB{TB: t}
+	start := time.Now()
+	result := mock.Run(tb)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the timeout to abort the operation, took %v", elapsed)
+	}
+
+	if result.Passed() {
+		t.Fatal("Expected the test to fail")
+	}
+	if !strings.Contains(result.Failure, "Hang failed: timed out after 20ms") {
+		t.Errorf("Expected a timeout failure, got %q", result.Failure)
+	}
+	if !tb.Failed() {
+		t.Error("Expected the timeout to be reported as an error")
+	}
+	if got := len(mock.Durations()); got != 1 {
+		t.Errorf("Expected the test to abort after the first operation, recorded %d", got)
+	}
+}
+
+func TestOperationTimeoutDisabled(t *testing.T) {
+	useResultCollector(t)
+	setFlag(t, "optimeout", "0")
+
+	mock := NewMockIntegrationTest("Slow", 40*time.Millisecond, 0)
+	mock.operations = []string{"Slow"}
+
+	if result := mock.Run(t); !result.Passed() {
+		t.Errorf("Expected the test to pass without a timeout, got %q", result.Failure)
+	}
+}
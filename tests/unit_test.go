@@ -57,4 +57,4 @@ func TestSliceOperations(t *testing.T) {
 			t.Errorf("Expected capacity 5, got %d", cap(slice))
 		}
 	})
-}
\ No newline at end of file
+}